@@ -0,0 +1,367 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/disgoorg/log"
+
+	"github.com/ftqo/kirby/assets"
+	"github.com/ftqo/kirby/card"
+	"github.com/ftqo/kirby/config"
+)
+
+const (
+	defaultHost         = "localhost"
+	defaultMaxBodyMiB   = 8
+	defaultFaceCacheMiB = 32
+	avatarTimeout       = 5 * time.Second
+	// avatars are drawn at a few hundred pixels at most, anything bigger is refused before it is decoded
+	maxAvatarSide = 1024
+	// longest text a card field can have, far more than fits on a card
+	maxTextRunes    = 300
+	maxRedirects    = 5
+	shutdownTimeout = 10 * time.Second
+	// how long a request waits for a free renderer before giving up
+	renderWait = 10 * time.Second
+)
+
+// welcomeRequest is the body of POST /v1/render/welcome, anything left out gets a default
+type welcomeRequest struct {
+	Layout     string `json:"layout"`
+	Background string `json:"background"`
+	Title      string `json:"title"`
+	Subtitle   string `json:"subtitle"`
+	Nickname   string `json:"nickname"`
+	Username   string `json:"username"`
+	Guild      string `json:"guild"`
+	Members    int    `json:"members"`
+	// avatar style, the layout's if left out
+	Shape  string `json:"shape"`
+	Shadow bool   `json:"shadow"`
+	Frame  string `json:"frame"`
+	// fetched unless an avatar is uploaded with a multipart request
+	AvatarURL string `json:"avatarUrl"`
+	Format    string `json:"format"`
+	Quality   int    `json:"quality"`
+}
+
+type server struct {
+	log     log.Logger
+	cfg     config.APIConfig
+	assets  *assets.Assets
+	maxBody int64
+	client  *http.Client
+	faces   *card.FaceCache
+	// limits how many cards are drawn at once, like the render queue's workers
+	renderers chan struct{}
+}
+
+// Run serves the render api on the configured port until ctx is done, doing nothing if no port is set
+func Run(ctx context.Context, wg *sync.WaitGroup, log log.Logger, cfg config.APIConfig, render config.RenderConfig, a *assets.Assets) {
+	defer wg.Done()
+	if cfg.Port == 0 {
+		log.Info("no api port set, not running api service")
+		return
+	}
+	log.Infof("running api service on %s:%d", cfg.Host, cfg.Port)
+	if cfg.Token == "" && cfg.Host != defaultHost && !net.ParseIP(cfg.Host).IsLoopback() {
+		log.Warn("api is listening beyond localhost without a token, anyone who can reach it can render cards")
+	}
+
+	if cfg.Host == "" {
+		cfg.Host = defaultHost
+	}
+	if render.Workers <= 0 {
+		render.Workers = 1
+	}
+	if render.MaxUploadMiB <= 0 {
+		render.MaxUploadMiB = defaultMaxBodyMiB
+	}
+	if render.FaceCacheMiB <= 0 {
+		render.FaceCacheMiB = defaultFaceCacheMiB
+	}
+	s := &server{
+		log:       log,
+		cfg:       cfg,
+		assets:    a,
+		maxBody:   int64(render.MaxUploadMiB) << 20,
+		faces:     card.NewFaceCache(int64(render.FaceCacheMiB) << 20),
+		renderers: make(chan struct{}, render.Workers),
+	}
+	s.client = &http.Client{Timeout: avatarTimeout, CheckRedirect: s.checkRedirect}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/render/welcome", s.handleWelcome)
+	srv := &http.Server{
+		Addr:              net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Info("gracefully shutting down api service")
+		sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err := srv.Shutdown(sctx)
+		if err != nil {
+			log.Errorf("failed to shut down api server: %v", err)
+		}
+	}()
+	err := srv.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Errorf("failed to serve api: %v", err)
+	}
+}
+
+// handleWelcome renders a welcome card from a json body, or a multipart form with the json in a request
+// field and the avatar in an avatar file
+func (s *server) handleWelcome(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "only POST is allowed")
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "missing or wrong api token")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxBody)
+
+	req := welcomeRequest{
+		Layout:     assets.DefaultLayout,
+		Background: "original",
+		Format:     card.FormatPNG,
+		Quality:    card.DefaultQuality,
+	}
+	var avatar image.Image
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		err := r.ParseMultipartForm(s.maxBody)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid form: "+err.Error())
+			return
+		}
+		if raw := r.FormValue("request"); raw != "" {
+			err = json.Unmarshal([]byte(raw), &req)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid request field: "+err.Error())
+				return
+			}
+		}
+		if f, _, err := r.FormFile("avatar"); err == nil {
+			avatar, err = decodeAvatar(f, s.maxBody)
+			f.Close()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid avatar: "+err.Error())
+				return
+			}
+		}
+	case "application/json", "":
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+			return
+		}
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, "send application/json or multipart/form-data")
+		return
+	}
+
+	for _, field := range []struct{ name, text string }{
+		{"title", req.Title},
+		{"subtitle", req.Subtitle},
+		{"nickname", req.Nickname},
+		{"username", req.Username},
+		{"guild", req.Guild},
+	} {
+		if utf8.RuneCountInString(field.text) > maxTextRunes {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("%s can be at most %d characters", field.name, maxTextRunes))
+			return
+		}
+	}
+	if !slices.Contains(card.Formats, req.Format) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown format %q, use one of %s", req.Format, strings.Join(card.Formats, ", ")))
+		return
+	}
+	l, ok := s.assets.Layouts[req.Layout]
+	if !ok || l.Internal {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown layout %q", req.Layout))
+		return
+	}
+	bg, ok := s.assets.Images[req.Background]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown background %q", req.Background))
+		return
+	}
+	if req.Shape != "" && !slices.Contains(assets.AvatarShapes, req.Shape) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown avatar shape %q, use one of %s", req.Shape, strings.Join(assets.AvatarShapes, ", ")))
+		return
+	}
+	if _, ok := s.assets.Frames[req.Frame]; req.Frame != "" && !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown avatar frame %q", req.Frame))
+		return
+	}
+	if avatar == nil && req.AvatarURL != "" {
+		var err error
+		avatar, err = s.fetchAvatar(r.Context(), req.AvatarURL)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "failed to get avatar: "+err.Error())
+			return
+		}
+	}
+	if avatar == nil {
+		avatar = card.DefaultAvatar(512)
+	}
+
+	wait, cancel := context.WithTimeout(r.Context(), renderWait)
+	defer cancel()
+	select {
+	case s.renderers <- struct{}{}:
+	case <-wait.Done():
+		writeError(w, http.StatusServiceUnavailable, "too busy to render, try again later")
+		return
+	}
+	raw, err := s.renderWelcome(l, bg, avatar, req)
+	<-s.renderers
+	if err != nil {
+		s.log.Errorf("failed to render welcome card for api: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to render card")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/"+req.Format)
+	w.Header().Set("Content-Length", strconv.Itoa(len(raw)))
+	w.Write(raw)
+}
+
+// authorized checks the request's bearer token, any request is allowed if no token is configured
+func (s *server) authorized(r *http.Request) bool {
+	if s.cfg.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Token)) == 1
+}
+
+// renderWelcome draws and encodes a card, turning panics into errors so the renderer slot is always given back
+func (s *server) renderWelcome(l *assets.Layout, bg, avatar image.Image, req welcomeRequest) (raw []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while rendering: %v", r)
+		}
+	}()
+	wc := card.Welcome{
+		Layout:     l,
+		Background: bg,
+		Theme:      card.Theme{Shape: req.Shape, Shadow: req.Shadow, Frame: req.Frame},
+		Faces:      s.faces,
+		Title:      req.Title,
+		Subtitle:   req.Subtitle,
+		Nickname:   req.Nickname,
+		Username:   req.Username,
+		Guild:      req.Guild,
+		Members:    req.Members,
+	}
+	img, err := wc.Render(s.assets, avatar)
+	if err != nil {
+		return nil, err
+	}
+	return card.Encode(img, card.Encoding{Format: req.Format, Quality: req.Quality})
+}
+
+// fetchAvatar downloads an avatar from a url on one of the configured hosts, no urls are allowed if there are none
+func (s *server) fetchAvatar(ctx context.Context, raw string) (image.Image, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, errors.New("avatar url must be an http or https url")
+	}
+	err = s.checkHost(u)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got status %s", resp.Status)
+	}
+	return decodeAvatar(resp.Body, s.maxBody)
+}
+
+func (s *server) checkHost(u *url.URL) error {
+	if len(s.cfg.AvatarHosts) == 0 {
+		return errors.New("avatar urls are turned off, upload the avatar instead")
+	}
+	if !slices.Contains(s.cfg.AvatarHosts, u.Hostname()) {
+		return fmt.Errorf("avatars can't be fetched from %s", u.Hostname())
+	}
+	return nil
+}
+
+// checkRedirect keeps redirects to the allowed hosts, so an allowed host can't send the fetch anywhere else
+func (s *server) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("too many redirects")
+	}
+	if req.URL.Scheme != "https" && req.URL.Scheme != "http" {
+		return errors.New("avatar url redirected to a non http url")
+	}
+	return s.checkHost(req.URL)
+}
+
+// decodeAvatar decodes an avatar of at most maxBytes, checking its dimensions before decoding the whole image
+func decodeAvatar(r io.Reader, maxBytes int64) (image.Image, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read avatar: %v", err)
+	}
+	if int64(len(raw)) > maxBytes {
+		return nil, fmt.Errorf("avatar is larger than %d MiB", maxBytes>>20)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, errors.New("avatar must be a png, jpeg, or gif")
+	}
+	if cfg.Width > maxAvatarSide || cfg.Height > maxAvatarSide {
+		return nil, fmt.Errorf("avatar can be at most %dx%d pixels", maxAvatarSide, maxAvatarSide)
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode avatar: %v", err)
+	}
+	return img, nil
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -5,6 +5,7 @@ import (
 	"embed"
 	"fmt"
 	"image"
+	"image/png"
 	"path"
 	"strings"
 
@@ -18,15 +19,42 @@ var fontsFS embed.FS
 //go:embed images
 var imagesFS embed.FS
 
+//go:embed layouts
+var layoutsFS embed.FS
+
+//go:embed emoji
+var emojiFS embed.FS
+
+//go:embed frames
+var framesFS embed.FS
+
+// DefaultLayout is the layout used when a guild hasn't picked one
+const DefaultLayout = "classic"
+
+// AvatarShapes are the shapes avatars can be clipped to
+var AvatarShapes = []string{"circle", "square", "rounded", "squircle", "hexagon"}
+
+// FallbackFonts are tried in order for characters missing from a layer's font. between them they
+// cover latin, greek, cyrillic, symbols and japanese. there is no hangul font, and text isn't shaped,
+// so arabic letters are drawn in their unjoined forms and right to left text isn't reordered
+var FallbackFonts = []string{"dejavusans", "mplus1p"}
+
 type Assets struct {
-	Images map[string]image.Image
-	Fonts  map[string]truetype.Font
+	Images  map[string]image.Image
+	Fonts   map[string]*truetype.Font
+	Layouts map[string]*Layout
+	Emoji   *Emoji
+	// Frames are overlays drawn over avatars, square with the avatar taking up the middle of them
+	Frames map[string]image.Image
 }
 
 func GetAssets(log log.Logger) (*Assets, error) {
 	a := &Assets{
-		Images: make(map[string]image.Image),
-		Fonts:  make(map[string]truetype.Font),
+		Images:  make(map[string]image.Image),
+		Fonts:   make(map[string]*truetype.Font),
+		Layouts: make(map[string]*Layout),
+		Emoji:   newEmoji(),
+		Frames:  make(map[string]image.Image),
 	}
 	err := a.LoadImages(log)
 	if err != nil {
@@ -36,6 +64,18 @@ func GetAssets(log log.Logger) (*Assets, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to load fonts: %v", err)
 	}
+	err = a.LoadFrames(log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load frames: %v", err)
+	}
+	err = a.LoadLayouts(log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load layouts: %v", err)
+	}
+	err = a.LoadEmoji(log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load emoji: %v", err)
+	}
 	return a, nil
 }
 
@@ -73,6 +113,9 @@ func (a *Assets) LoadFonts(log log.Logger) error {
 	}
 	for _, file := range files {
 		fname := file.Name()
+		if path.Ext(fname) != ".ttf" {
+			continue
+		}
 		raw, err := fontsFS.ReadFile(path.Join("fonts", fname))
 		if err != nil {
 			log.Panicf("failed to read file %s: %v", fname, err)
@@ -82,9 +125,84 @@ func (a *Assets) LoadFonts(log log.Logger) error {
 		if err != nil {
 			return fmt.Errorf("failed to parse font %s: %v", fname, err)
 		}
-		a.Fonts[name] = *font
+		a.Fonts[name] = font
 
 		log.Debugf("loaded %s", fname)
 	}
 	return nil
 }
+
+func (a *Assets) LoadEmoji(log log.Logger) error {
+	log.Info("loading emoji")
+	files, err := emojiFS.ReadDir("emoji")
+	if err != nil {
+		return fmt.Errorf("failed to read embedded emoji directory: %v", err)
+	}
+	for _, file := range files {
+		fname := file.Name()
+		if path.Ext(fname) != ".png" {
+			continue
+		}
+		raw, err := emojiFS.ReadFile(path.Join("emoji", fname))
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %v", fname, err)
+		}
+		_, err = png.DecodeConfig(bytes.NewReader(raw))
+		if err != nil {
+			return fmt.Errorf("failed to decode emoji %s: %v", fname, err)
+		}
+		a.Emoji.raw[strings.ToLower(strings.TrimSuffix(fname, ".png"))] = raw
+	}
+	log.Debugf("loaded %d emoji", a.Emoji.Len())
+	return nil
+}
+
+func (a *Assets) LoadFrames(log log.Logger) error {
+	log.Info("loading frames into memory")
+	files, err := framesFS.ReadDir("frames")
+	if err != nil {
+		return fmt.Errorf("failed to read embedded frames directory: %v", err)
+	}
+	for _, file := range files {
+		fname := file.Name()
+		if path.Ext(fname) != ".png" {
+			continue
+		}
+		raw, err := framesFS.ReadFile(path.Join("frames", fname))
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %v", fname, err)
+		}
+		name := fname[strings.LastIndex(fname, "-")+1 : strings.Index(fname, ".")]
+		img, format, err := image.Decode(bytes.NewReader(raw))
+		if err != nil {
+			return fmt.Errorf("failed to decode frame %s: %v", fname, err)
+		}
+		if format != "png" {
+			return fmt.Errorf("failed to decode %s as png (formatted as %s)", fname, format)
+		}
+		if img.Bounds().Dx() != img.Bounds().Dy() {
+			return fmt.Errorf("frame %s is not square", fname)
+		}
+		a.Frames[name] = img
+		log.Debugf("loaded %s", fname)
+	}
+	return nil
+}
+
+// FontChain returns the font with the given name followed by the fallback fonts
+func (a *Assets) FontChain(name string) ([]*truetype.Font, error) {
+	f, ok := a.Fonts[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown font %q", name)
+	}
+	chain := []*truetype.Font{f}
+	for _, fallback := range FallbackFonts {
+		if fallback == name {
+			continue
+		}
+		if f, ok := a.Fonts[fallback]; ok {
+			chain = append(chain, f)
+		}
+	}
+	return chain, nil
+}
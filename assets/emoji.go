@@ -0,0 +1,46 @@
+package assets
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"sync"
+)
+
+// Emoji holds the embedded emoji pngs. there are thousands and a card uses a few at most, so each is
+// only decoded the first time it is drawn
+type Emoji struct {
+	raw map[string][]byte
+
+	mu      sync.Mutex
+	decoded map[string]image.Image
+}
+
+func newEmoji() *Emoji {
+	return &Emoji{raw: make(map[string][]byte), decoded: make(map[string]image.Image)}
+}
+
+// Image returns the emoji keyed by its codepoints in hex joined by dashes, e.g. 1f44b
+func (e *Emoji) Image(name string) (image.Image, bool) {
+	raw, ok := e.raw[name]
+	if !ok {
+		return nil, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if img, ok := e.decoded[name]; ok {
+		return img, true
+	}
+	// checked when loaded, so this only fails if the embedded file is corrupt past its header
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false
+	}
+	e.decoded[name] = img
+	return img, true
+}
+
+// Len returns how many emoji there are
+func (e *Emoji) Len() int {
+	return len(e.raw)
+}
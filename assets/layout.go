@@ -0,0 +1,251 @@
+package assets
+
+import (
+	"fmt"
+	"image/color"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/disgoorg/log"
+	"gopkg.in/yaml.v2"
+)
+
+// Layout describes the geometry of a card as a stack of layers, drawn in order
+type Layout struct {
+	Name   string `yaml:"name"`
+	Width  int    `yaml:"width"`
+	Height int    `yaml:"height"`
+	// internal layouts are for kirby's own cards, like collages, and can't be picked by guilds
+	Internal bool    `yaml:"internal"`
+	Layers   []Layer `yaml:"layers"`
+}
+
+// Layer is a single element of a layout, the fields used depend on its type
+type Layer struct {
+	// background, rect, avatar, avatars, or text
+	Type string `yaml:"type"`
+
+	// rect and avatars: top left corner; avatar and text: anchor point
+	X float64 `yaml:"x"`
+	Y float64 `yaml:"y"`
+
+	// rect and avatars
+	Width  float64 `yaml:"width"`
+	Height float64 `yaml:"height"`
+	Radius float64 `yaml:"radius"`
+
+	// avatar and avatars, which lays out a grid of avatars no bigger than size
+	Size   float64 `yaml:"size"`
+	Shape  string  `yaml:"shape"`
+	Border Border  `yaml:"border"`
+	Shadow bool    `yaml:"shadow"`
+	// the name of a frame drawn over the avatar, none if empty
+	Frame string `yaml:"frame"`
+
+	// text
+	Text     string  `yaml:"text"`
+	Font     string  `yaml:"font"`
+	FontSize float64 `yaml:"fontSize"`
+	Anchor   Anchor  `yaml:"anchor"`
+	// text that is wider than max width is shrunk down to min font size, then wrapped
+	// over up to max lines, and then cut off with an ellipsis. a max width of 0 disables fitting
+	MaxWidth    float64 `yaml:"maxWidth"`
+	MinFontSize float64 `yaml:"minFontSize"`
+	MaxLines    int     `yaml:"maxLines"`
+	LineSpacing float64 `yaml:"lineSpacing"`
+
+	Color Color `yaml:"color"`
+}
+
+type Border struct {
+	Width float64 `yaml:"width"`
+	Color Color   `yaml:"color"`
+}
+
+// Color is a color written as #rrggbb or #rrggbbaa
+type Color struct {
+	color.NRGBA
+}
+
+func (c *Color) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	err := unmarshal(&s)
+	if err != nil {
+		return err
+	}
+	c.NRGBA, err = ParseColor(s)
+	return err
+}
+
+// ParseColor parses colors in the form of #rrggbb or #rrggbbaa
+func ParseColor(s string) (color.NRGBA, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 && len(s) != 8 {
+		return color.NRGBA{}, fmt.Errorf("%q is not a 6 or 8 digit hex color", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("%q is not a valid hex color: %v", s, err)
+	}
+	if len(s) == 6 {
+		v = v<<8 | 0xff
+	}
+	return color.NRGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
+
+// Anchor is the point of a text that is placed at its position, as fractions of its width and height
+type Anchor struct {
+	X, Y float64
+
+	set bool
+}
+
+var anchors = map[string]Anchor{
+	"top-left":     {0, 0, true},
+	"top":          {0.5, 0, true},
+	"top-right":    {1, 0, true},
+	"left":         {0, 0.5, true},
+	"center":       {0.5, 0.5, true},
+	"right":        {1, 0.5, true},
+	"bottom-left":  {0, 1, true},
+	"bottom":       {0.5, 1, true},
+	"bottom-right": {1, 1, true},
+}
+
+func (an *Anchor) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	err := unmarshal(&s)
+	if err != nil {
+		return err
+	}
+	a, ok := anchors[s]
+	if !ok {
+		return fmt.Errorf("unknown anchor %q", s)
+	}
+	*an = a
+	return nil
+}
+
+func (a *Assets) LoadLayouts(log log.Logger) error {
+	log.Info("loading layouts into memory")
+	files, err := layoutsFS.ReadDir("layouts")
+	if err != nil {
+		return fmt.Errorf("failed to read embedded layouts directory: %v", err)
+	}
+	for _, file := range files {
+		fname := file.Name()
+		raw, err := layoutsFS.ReadFile(path.Join("layouts", fname))
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %v", fname, err)
+		}
+		l, err := ParseLayout(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse layout %s: %v", fname, err)
+		}
+		err = a.ValidateLayout(l)
+		if err != nil {
+			return fmt.Errorf("invalid layout %s: %v", fname, err)
+		}
+		a.Layouts[l.Name] = l
+
+		log.Debugf("loaded %s", fname)
+	}
+	if _, ok := a.Layouts[DefaultLayout]; !ok {
+		return fmt.Errorf("default layout %s is missing", DefaultLayout)
+	}
+	return nil
+}
+
+// ParseLayout parses a layout from yaml, filling in defaults
+func ParseLayout(raw []byte) (*Layout, error) {
+	l := &Layout{}
+	err := yaml.UnmarshalStrict(raw, l)
+	if err != nil {
+		return nil, err
+	}
+	for i := range l.Layers {
+		ly := &l.Layers[i]
+		if ly.Type == "text" && !ly.Anchor.set {
+			ly.Anchor = anchors["center"]
+		}
+		if ly.Type == "text" && ly.Color == (Color{}) {
+			ly.Color = Color{color.NRGBA{255, 255, 255, 255}}
+		}
+		if ly.Type == "text" && ly.MinFontSize == 0 {
+			ly.MinFontSize = ly.FontSize
+		}
+		if ly.Type == "text" && ly.MaxLines == 0 {
+			ly.MaxLines = 1
+		}
+		if ly.Type == "text" && ly.LineSpacing == 0 {
+			ly.LineSpacing = 1.2
+		}
+		if (ly.Type == "avatar" || ly.Type == "avatars") && ly.Shape == "" {
+			ly.Shape = "circle"
+		}
+	}
+	return l, nil
+}
+
+// ValidateLayout checks that a layout only references known layer types, shapes, frames, and fonts
+func (a *Assets) ValidateLayout(l *Layout) error {
+	if l.Name == "" {
+		return fmt.Errorf("layout has no name")
+	}
+	if l.Width <= 0 || l.Height <= 0 {
+		return fmt.Errorf("layout size must be positive")
+	}
+	for i, ly := range l.Layers {
+		switch ly.Type {
+		case "background":
+		case "rect":
+			if ly.Width <= 0 || ly.Height <= 0 {
+				return fmt.Errorf("layer %d: rect size must be positive", i)
+			}
+		case "avatar":
+			if ly.Size <= 0 {
+				return fmt.Errorf("layer %d: avatar size must be positive", i)
+			}
+			err := a.validateAvatar(ly)
+			if err != nil {
+				return fmt.Errorf("layer %d: %v", i, err)
+			}
+		case "avatars":
+			if ly.Width <= 0 || ly.Height <= 0 || ly.Size <= 0 {
+				return fmt.Errorf("layer %d: avatars size must be positive", i)
+			}
+			err := a.validateAvatar(ly)
+			if err != nil {
+				return fmt.Errorf("layer %d: %v", i, err)
+			}
+		case "text":
+			if _, ok := a.Fonts[ly.Font]; !ok {
+				return fmt.Errorf("layer %d: unknown font %q", i, ly.Font)
+			}
+			if ly.FontSize <= 0 {
+				return fmt.Errorf("layer %d: font size must be positive", i)
+			}
+			if ly.MinFontSize <= 0 || ly.MinFontSize > ly.FontSize {
+				return fmt.Errorf("layer %d: min font size must be between 0 and the font size", i)
+			}
+			if ly.MaxWidth < 0 || ly.MaxLines < 1 || ly.LineSpacing <= 0 {
+				return fmt.Errorf("layer %d: max width, max lines, and line spacing must be positive", i)
+			}
+		default:
+			return fmt.Errorf("layer %d: unknown layer type %q", i, ly.Type)
+		}
+	}
+	return nil
+}
+
+func (a *Assets) validateAvatar(ly Layer) error {
+	if !slices.Contains(AvatarShapes, ly.Shape) {
+		return fmt.Errorf("unknown avatar shape %q", ly.Shape)
+	}
+	if _, ok := a.Frames[ly.Frame]; ly.Frame != "" && !ok {
+		return fmt.Errorf("unknown avatar frame %q", ly.Frame)
+	}
+	return nil
+}
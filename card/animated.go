@@ -0,0 +1,154 @@
+package card
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"sync"
+
+	"github.com/ftqo/kirby/assets"
+)
+
+// ErrOverBudget is returned when a card can't be made to fit its size budget
+var ErrOverBudget = errors.New("card is over budget")
+
+// Budget limits how big an animated card may get
+type Budget struct {
+	MaxFrames int
+	MaxBytes  int
+}
+
+// RenderAnimated draws a card for every frame of an animated avatar and encodes them as a gif
+func RenderAnimated(a *assets.Assets, l *assets.Layout, d Data, avatar *gif.GIF, b Budget) ([]byte, error) {
+	if len(avatar.Image) == 0 {
+		return nil, fmt.Errorf("avatar has no frames")
+	}
+	if len(avatar.Image) > b.MaxFrames {
+		return nil, ErrOverBudget
+	}
+
+	out := &gif.GIF{LoopCount: avatar.LoopCount}
+	var previous *image.RGBA
+	for i, frame := range coalesce(avatar) {
+		d.Avatar = frame
+		img, err := Render(a, l, d)
+		if err != nil {
+			return nil, err
+		}
+		rgba := toRGBA(img)
+
+		// only the parts that changed since the last frame need to be stored
+		bounds := rgba.Bounds()
+		if previous != nil {
+			bounds = changed(previous, rgba)
+		}
+		out.Image = append(out.Image, quantize(rgba, bounds))
+		out.Delay = append(out.Delay, avatar.Delay[i])
+		previous = rgba
+	}
+
+	buf := bytes.Buffer{}
+	err := gif.EncodeAll(&buf, out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode gif: %v", err)
+	}
+	if buf.Len() > b.MaxBytes {
+		return nil, ErrOverBudget
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	plan9Once  sync.Once
+	plan9Table [1 << 15]uint8
+)
+
+// quantize maps the pixels of img inside r to the plan9 palette, using a lookup table of 5 bit colors
+func quantize(img *image.RGBA, r image.Rectangle) *image.Paletted {
+	plan9Once.Do(func() {
+		for k := range plan9Table {
+			c := color.RGBA{uint8(k>>10) << 3, uint8(k>>5&0x1f) << 3, uint8(k&0x1f) << 3, 0xff}
+			plan9Table[k] = uint8(color.Palette(palette.Plan9).Index(c))
+		}
+	})
+	p := image.NewPaletted(r, palette.Plan9)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		src := img.Pix[img.PixOffset(r.Min.X, y):]
+		dst := p.Pix[p.PixOffset(r.Min.X, y):]
+		for x := 0; x < r.Dx(); x++ {
+			k := int(src[4*x]>>3)<<10 | int(src[4*x+1]>>3)<<5 | int(src[4*x+2]>>3)
+			dst[x] = plan9Table[k]
+		}
+	}
+	return p
+}
+
+// changed returns the smallest rectangle containing every pixel that differs between a and b
+func changed(a, b *image.RGBA) image.Rectangle {
+	r := image.Rectangle{}
+	bounds := b.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i := b.PixOffset(x, y)
+			if a.Pix[i] != b.Pix[i] || a.Pix[i+1] != b.Pix[i+1] || a.Pix[i+2] != b.Pix[i+2] {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if r.Empty() {
+		// gif frames can't be empty
+		return image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Min.X+1, bounds.Min.Y+1)
+	}
+	return r
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba
+}
+
+// coalesce turns the (possibly partial) frames of a gif into full frames
+func coalesce(g *gif.GIF) []image.Image {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = g.Image[0].Bounds()
+	}
+	canvas := image.NewRGBA(bounds)
+	frames := make([]image.Image, len(g.Image))
+	for i, p := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = clone(canvas)
+		}
+
+		draw.Draw(canvas, p.Bounds(), p, p.Bounds().Min, draw.Over)
+		frames[i] = clone(canvas)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, p.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return frames
+}
+
+func clone(img *image.RGBA) *image.RGBA {
+	c := image.NewRGBA(img.Bounds())
+	copy(c.Pix, img.Pix)
+	return c
+}
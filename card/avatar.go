@@ -0,0 +1,30 @@
+package card
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/fogleman/gg"
+)
+
+var (
+	defaultAvatarBackground = color.NRGBA{0x58, 0x65, 0xf2, 0xff}
+	defaultAvatarForeground = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// DefaultAvatar draws a plain silhouette, used when a member's avatar can't be fetched
+func DefaultAvatar(size int) image.Image {
+	s := float64(size)
+	dc := gg.NewContext(size, size)
+	dc.SetColor(defaultAvatarBackground)
+	dc.Clear()
+
+	dc.SetColor(defaultAvatarForeground)
+	// head
+	dc.DrawCircle(s/2, s*0.38, s*0.18)
+	dc.Fill()
+	// shoulders, cut off by the bottom of the image
+	dc.DrawEllipse(s/2, s*0.92, s*0.32, s*0.26)
+	dc.Fill()
+	return dc.Image()
+}
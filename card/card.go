@@ -0,0 +1,265 @@
+package card
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/anthonynsimon/bild/blur"
+	"github.com/anthonynsimon/bild/transform"
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+
+	"github.com/ftqo/kirby/assets"
+)
+
+const (
+	// how much bigger a frame is drawn than the avatar it goes around
+	frameScale = 1.2
+	// the corner radius of rounded avatars, as a fraction of half their size
+	roundedRadius = 0.3
+	// how many points squircles are drawn with
+	squircleSteps = 128
+	// shadow offset and blur as fractions of the avatar size
+	shadowOffset = 0.04
+	shadowBlur   = 0.04
+)
+
+var shadowColor = color.NRGBA{0, 0, 0, 0x90}
+
+// Data is everything that changes between two cards drawn with the same layout
+type Data struct {
+	Background image.Image
+	Avatar     image.Image
+	// Avatars fill avatars layers, for cards about several members
+	Avatars []image.Image
+	// Texts replaces %key% placeholders in text layers
+	Texts map[string]string
+	// Base is the layout's static layers drawn by RenderBase, skipped when set
+	Base  image.Image
+	Theme Theme
+	// Font replaces the font of text layers, which still fall back to their own font
+	Font *truetype.Font
+	// Faces keeps font faces between renders, they are made for every render if nil
+	Faces *FaceCache
+}
+
+// Render draws a card by drawing each layer of the layout in order
+func Render(a *assets.Assets, l *assets.Layout, d Data) (image.Image, error) {
+	dc := gg.NewContext(l.Width, l.Height)
+	r := replacer(d.Texts)
+
+	start := 0
+	if d.Base != nil {
+		dc.DrawImage(d.Base, 0, 0)
+		start = StaticLayers(l)
+	}
+	for i := start; i < len(l.Layers); i++ {
+		ly := d.Theme.apply(l.Layers[i])
+		var err error
+		switch ly.Type {
+		case "background":
+			drawBackground(dc, d.Background)
+		case "rect":
+			drawRect(dc, ly)
+		case "avatar":
+			drawAvatar(dc, a, ly, d.Avatar)
+		case "avatars":
+			drawAvatars(dc, a, ly, d.Avatars)
+		case "text":
+			err = drawText(dc, a, ly, d.Font, d.Faces, r.Replace(ly.Text))
+		default:
+			err = fmt.Errorf("unknown layer type %q", ly.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to draw layer %d: %v", i, err)
+		}
+	}
+	return dc.Image(), nil
+}
+
+// RenderBase draws the static layers of a layout, which don't change between members of the same guild
+func RenderBase(a *assets.Assets, l *assets.Layout, bg image.Image, t Theme) (image.Image, error) {
+	static := *l
+	static.Layers = l.Layers[:StaticLayers(l)]
+	return Render(a, &static, Data{Background: bg, Theme: t})
+}
+
+// StaticLayers counts the layers at the start of a layout that don't depend on the member
+func StaticLayers(l *assets.Layout) int {
+	for i, ly := range l.Layers {
+		if ly.Type != "background" && ly.Type != "rect" {
+			return i
+		}
+	}
+	return len(l.Layers)
+}
+
+func replacer(texts map[string]string) *strings.Replacer {
+	keys := make([]string, 0, len(texts))
+	for k := range texts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, "%"+k+"%", texts[k])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func drawBackground(dc *gg.Context, bg image.Image) {
+	if bg == nil {
+		return
+	}
+	if bg.Bounds().Dx() != dc.Width() || bg.Bounds().Dy() != dc.Height() {
+		bg = CoverCrop(bg, dc.Width(), dc.Height())
+	}
+	dc.DrawImage(bg, 0, 0)
+}
+
+func drawRect(dc *gg.Context, ly assets.Layer) {
+	dc.SetColor(ly.Color)
+	if ly.Radius > 0 {
+		dc.DrawRoundedRectangle(ly.X, ly.Y, ly.Width, ly.Height, ly.Radius)
+	} else {
+		dc.DrawRectangle(ly.X, ly.Y, ly.Width, ly.Height)
+	}
+	dc.Fill()
+}
+
+func drawAvatar(dc *gg.Context, a *assets.Assets, ly assets.Layer, avatar image.Image) {
+	if avatar == nil {
+		return
+	}
+	size := int(ly.Size)
+	if avatar.Bounds().Dx() != size || avatar.Bounds().Dy() != size {
+		avatar = transform.Resize(avatar, size, size, transform.Linear)
+	}
+
+	if ly.Shadow {
+		drawShadow(dc, ly)
+	}
+
+	// draw outline
+	if ly.Border.Width > 0 {
+		dc.SetColor(ly.Border.Color)
+		dc.SetLineWidth(ly.Border.Width)
+		avatarPath(dc, ly, ly.Border.Width/2)
+		dc.Stroke()
+	}
+
+	// draw avatar clipped to its shape
+	avatarPath(dc, ly, 0)
+	dc.Clip()
+	dc.DrawImageAnchored(avatar, int(ly.X), int(ly.Y), 0.5, 0.5)
+	dc.ResetClip()
+
+	if frame, ok := a.Frames[ly.Frame]; ok {
+		side := int(ly.Size*frameScale + 0.5)
+		frame = transform.Resize(frame, side, side, transform.Linear)
+		dc.DrawImageAnchored(frame, int(ly.X), int(ly.Y), 0.5, 0.5)
+	}
+}
+
+// drawShadow draws a soft shadow of the avatar's shape and border, a little below it
+func drawShadow(dc *gg.Context, ly assets.Layer) {
+	radius := ly.Size * shadowBlur
+	// leave room around the shape for the blur to fade out
+	margin := math.Ceil(ly.Border.Width + 2*radius)
+	side := int(ly.Size + 2*margin)
+	sc := gg.NewContext(side, side)
+	sl := ly
+	sl.X, sl.Y = float64(side)/2, float64(side)/2
+	avatarPath(sc, sl, ly.Border.Width)
+	sc.SetColor(shadowColor)
+	sc.Fill()
+	shadow := blur.Gaussian(sc.Image(), radius)
+	dc.DrawImageAnchored(shadow, int(ly.X), int(ly.Y+ly.Size*shadowOffset), 0.5, 0.5)
+}
+
+// drawAvatars draws avatars in the biggest grid that fits the layer, centered within it
+func drawAvatars(dc *gg.Context, a *assets.Assets, ly assets.Layer, avatars []image.Image) {
+	n := len(avatars)
+	if n == 0 {
+		return
+	}
+	cols, cell := 1, 0.0
+	for c := 1; c <= n; c++ {
+		rows := (n + c - 1) / c
+		size := math.Min(ly.Width/float64(c), ly.Height/float64(rows))
+		if size > cell {
+			cols, cell = c, size
+		}
+	}
+	rows := (n + cols - 1) / cols
+	// leave a gap between avatars for their borders
+	size := math.Min(cell*0.85, ly.Size)
+
+	top := ly.Y + (ly.Height-cell*float64(rows))/2
+	for i, avatar := range avatars {
+		row, col := i/cols, i%cols
+		// center the last row if it isn't full
+		inRow := cols
+		if row == rows-1 && n%cols != 0 {
+			inRow = n % cols
+		}
+		left := ly.X + (ly.Width-cell*float64(inRow))/2
+		cl := ly
+		cl.Size = size
+		cl.X = left + cell*(float64(col)+0.5)
+		cl.Y = top + cell*(float64(row)+0.5)
+		drawAvatar(dc, a, cl, avatar)
+	}
+}
+
+// avatarPath adds the outline of the avatar's shape to the current path, grown by pad on every side
+func avatarPath(dc *gg.Context, ly assets.Layer, pad float64) {
+	r := ly.Size/2 + pad
+	switch ly.Shape {
+	case "square":
+		dc.DrawRectangle(ly.X-r, ly.Y-r, 2*r, 2*r)
+	case "rounded":
+		dc.DrawRoundedRectangle(ly.X-r, ly.Y-r, 2*r, 2*r, ly.Size/2*roundedRadius+pad)
+	case "squircle":
+		squirclePath(dc, ly.X, ly.Y, r)
+	case "hexagon":
+		// pointy side up, padded evenly along the flat sides
+		dc.DrawRegularPolygon(6, ly.X, ly.Y, ly.Size/2+pad/math.Cos(math.Pi/6), math.Pi/6)
+	default:
+		dc.DrawCircle(ly.X, ly.Y, r)
+	}
+}
+
+// squirclePath adds a superellipse with an exponent of 4 to the current path, somewhere between a square and a circle
+func squirclePath(dc *gg.Context, x, y, r float64) {
+	dc.NewSubPath()
+	for i := 0; i < squircleSteps; i++ {
+		t := 2 * math.Pi * float64(i) / squircleSteps
+		c, s := math.Cos(t), math.Sin(t)
+		dc.LineTo(x+r*math.Copysign(math.Sqrt(math.Abs(c)), c), y+r*math.Copysign(math.Sqrt(math.Abs(s)), s))
+	}
+	dc.ClosePath()
+}
+
+// CoverCrop scales img to completely cover a w by h rectangle, cropping off the overflow around the center
+func CoverCrop(img image.Image, w, h int) image.Image {
+	b := img.Bounds()
+	scale := float64(w) / float64(b.Dx())
+	if s := float64(h) / float64(b.Dy()); s > scale {
+		scale = s
+	}
+	sw, sh := int(float64(b.Dx())*scale+0.5), int(float64(b.Dy())*scale+0.5)
+	if sw < w {
+		sw = w
+	}
+	if sh < h {
+		sh = h
+	}
+	scaled := transform.Resize(img, sw, sh, transform.Linear)
+	x, y := (sw-w)/2, (sh-h)/2
+	return transform.Crop(scaled, image.Rect(x, y, x+w, y+h))
+}
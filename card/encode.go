@@ -0,0 +1,115 @@
+package card
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/anthonynsimon/bild/transform"
+)
+
+const (
+	FormatPNG  = "png"
+	FormatJPEG = "jpeg"
+	// webp is always lossless, so quality only matters for jpeg
+	FormatWebP = "webp"
+
+	DefaultQuality = 90
+	// jpeg quality is lowered in steps down to this before the card is scaled down instead
+	minQuality  = 50
+	qualityStep = 10
+	// each scaling step shrinks the card to this fraction of its size, stopping at minScale
+	scaleStep = 0.8
+	minScale  = 0.3
+)
+
+// Formats are the output formats Encode supports
+var Formats = []string{FormatPNG, FormatJPEG, FormatWebP}
+
+// Encoding is how a still card gets encoded
+type Encoding struct {
+	Format string
+	// jpeg quality from 1 to 100, ignored by png and webp
+	Quality int
+	// the encoded card is shrunk until it fits, or ignored if zero
+	MaxBytes int
+}
+
+// Extension returns the file extension for a format, including the dot
+func Extension(format string) string {
+	switch format {
+	case FormatJPEG:
+		return ".jpg"
+	case FormatWebP:
+		return ".webp"
+	default:
+		return ".png"
+	}
+}
+
+// Encode encodes img, lowering the quality and then the resolution until it fits within e.MaxBytes.
+// only jpeg has a quality, png and webp are lossless so they go straight to lowering the resolution
+func Encode(img image.Image, e Encoding) ([]byte, error) {
+	if e.Quality < 1 || e.Quality > 100 {
+		e.Quality = DefaultQuality
+	}
+	if e.Format == FormatJPEG {
+		img = flatten(img)
+	}
+
+	b := img.Bounds()
+	scaled := img
+	scale := 1.0
+	for {
+		raw, err := encode(scaled, e.Format, e.Quality)
+		if err != nil {
+			return nil, err
+		}
+		if e.MaxBytes <= 0 || len(raw) <= e.MaxBytes {
+			return raw, nil
+		}
+		if e.Format == FormatJPEG && e.Quality > minQuality {
+			e.Quality -= qualityStep
+			if e.Quality < minQuality {
+				e.Quality = minQuality
+			}
+			continue
+		}
+		scale *= scaleStep
+		if scale < minScale {
+			return nil, fmt.Errorf("%w: %s is %d bytes at the smallest size", ErrOverBudget, e.Format, len(raw))
+		}
+		scaled = transform.Resize(img, int(float64(b.Dx())*scale), int(float64(b.Dy())*scale), transform.Linear)
+	}
+}
+
+func encode(img image.Image, format string, quality int) ([]byte, error) {
+	buf := bytes.Buffer{}
+	var err error
+	switch format {
+	case FormatPNG:
+		err = png.Encode(&buf, img)
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	case FormatWebP:
+		err = nativewebp.Encode(&buf, img, nil)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %v", format, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// flatten draws img over black, since jpeg has no transparency
+func flatten(img image.Image) image.Image {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.Black, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+	return flat
+}
@@ -0,0 +1,89 @@
+package card
+
+import (
+	"sync"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/ftqo/kirby/lru"
+)
+
+const (
+	// how many faces of the same font and size are kept, about as many as cards are drawn at once
+	maxPooledFaces = 4
+	// glyphs each face keeps rasterized, a card only has a few dozen different ones
+	glyphCacheEntries = 128
+)
+
+// FaceCache keeps the faces made for each font and size between renders, since making them is slow.
+// a face can't be drawn with by two renders at once, so renders take faces out and put them back when done
+type FaceCache struct {
+	cache *lru.Cache[faceKey, *facePool]
+}
+
+type faceKey struct {
+	font *truetype.Font
+	size float64
+}
+
+type facePool struct {
+	key  faceKey
+	mu   sync.Mutex
+	free []font.Face
+}
+
+func NewFaceCache(maxBytes int64) *FaceCache {
+	return &FaceCache{cache: lru.New[faceKey, *facePool](maxBytes)}
+}
+
+// Stats returns the cache's counters, counting a hit whenever a font and size has been drawn with before
+func (c *FaceCache) Stats() lru.Stats {
+	return c.cache.Stats()
+}
+
+// take returns a face of f at size along with the pool to put it back in, making a face if there are
+// none to reuse. a nil cache always makes a new face and has no pool
+func (c *FaceCache) take(f *truetype.Font, size float64) (font.Face, *facePool) {
+	var p *facePool
+	if c != nil {
+		key := faceKey{f, size}
+		var ok bool
+		p, ok = c.cache.Get(key)
+		if !ok {
+			p = &facePool{key: key}
+			c.cache.Add(key, p, 0)
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if n := len(p.free); n > 0 {
+			face := p.free[n-1]
+			p.free = p.free[:n-1]
+			return face, p
+		}
+	}
+	return truetype.NewFace(f, &truetype.Options{Size: size, GlyphCacheEntries: glyphCacheEntries}), p
+}
+
+// put hands a face back to the pool it was taken from for the next render
+func (c *FaceCache) put(p *facePool, face font.Face) {
+	if c == nil || p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.free) >= maxPooledFaces {
+		return
+	}
+	p.free = append(p.free, face)
+	// adding again updates the cost to however many faces the pool holds now
+	c.cache.Add(p.key, p, faceCost(p.key.font, p.key.size)*int64(len(p.free)))
+}
+
+// faceCost estimates the bytes a face takes up, which is mostly its cache of rasterized glyphs
+func faceCost(f *truetype.Font, size float64) int64 {
+	b := f.Bounds(fixed.Int26_6(size*64 + 0.5))
+	w, h := (b.Max.X-b.Min.X).Ceil()+2, (b.Max.Y-b.Min.Y).Ceil()+2
+	return int64(w) * int64(h) * glyphCacheEntries
+}
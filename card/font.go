@@ -0,0 +1,246 @@
+package card
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/anthonynsimon/bild/transform"
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+
+	"github.com/ftqo/kirby/assets"
+)
+
+const (
+	// longest emoji sequence looked up, e.g. family emoji joined by zero width joiners
+	maxEmojiRunes = 8
+	// runes below this, like digits, # and ©, are text unless followed by one of these
+	textPresentation = 0x2000
+	emojiSelector    = 0xfe0f
+	keycap           = 0x20e3
+)
+
+// fontChain draws each rune with the first font in the chain that has it, and emoji as images
+type fontChain struct {
+	fonts []*truetype.Font
+	faces []font.Face
+	emoji *assets.Emoji
+	size  float64
+	// faces already taken for each size tried, and the pools they go back to on release
+	sized map[float64][]font.Face
+	pools map[float64][]*facePool
+	cache *FaceCache
+}
+
+// run is a piece of text drawn with a single face, or a single emoji
+type run struct {
+	face  int
+	text  string
+	emoji image.Image
+}
+
+// newFontChain makes the font chain for a layer's font, with primary ahead of it if set
+func newFontChain(a *assets.Assets, name string, primary *truetype.Font, cache *FaceCache) (*fontChain, error) {
+	fonts, err := a.FontChain(name)
+	if err != nil {
+		return nil, err
+	}
+	if primary != nil {
+		fonts = append([]*truetype.Font{primary}, fonts...)
+	}
+	return &fontChain{fonts: fonts, emoji: a.Emoji, sized: make(map[float64][]font.Face), pools: make(map[float64][]*facePool), cache: cache}, nil
+}
+
+func (fc *fontChain) setSize(size float64) {
+	fc.size = size
+	if faces, ok := fc.sized[size]; ok {
+		fc.faces = faces
+		return
+	}
+	fc.faces = make([]font.Face, len(fc.fonts))
+	pools := make([]*facePool, len(fc.fonts))
+	for i, f := range fc.fonts {
+		fc.faces[i], pools[i] = fc.cache.take(f, size)
+	}
+	fc.sized[size] = fc.faces
+	fc.pools[size] = pools
+}
+
+// release gives the chain's faces back to its cache, after which it can't be drawn with
+func (fc *fontChain) release() {
+	for size, faces := range fc.sized {
+		for i, face := range faces {
+			fc.cache.put(fc.pools[size][i], face)
+		}
+	}
+	fc.sized = nil
+	fc.faces = nil
+	fc.pools = nil
+}
+
+// height is the height of a line, matching gg's font height
+func (fc *fontChain) height() float64 {
+	return fc.size * 72 / 96
+}
+
+// runs splits text into runs of the same face, falling back to the first font if no font has a rune
+func (fc *fontChain) runs(text string) []run {
+	var runs []run
+	rs := []rune(text)
+	// the text run being built, from start up to the current rune
+	start, face := 0, -1
+	flush := func(end int) {
+		if face >= 0 && end > start {
+			runs = append(runs, run{face: face, text: string(rs[start:end])})
+		}
+	}
+	for i := 0; i < len(rs); {
+		if img, n := fc.lookupEmoji(rs[i:]); img != nil {
+			flush(i)
+			runs = append(runs, run{face: -1, text: string(rs[i : i+n]), emoji: img})
+			i += n
+			start, face = i, -1
+			continue
+		}
+		f := 0
+		for j, ft := range fc.fonts {
+			if ft.Index(rs[i]) != 0 {
+				f = j
+				break
+			}
+		}
+		if f != face {
+			flush(i)
+			start, face = i, f
+		}
+		i++
+	}
+	flush(len(rs))
+	return runs
+}
+
+// lookupEmoji finds the longest emoji at the start of rs, returning it and how many runes it spans
+func (fc *fontChain) lookupEmoji(rs []rune) (image.Image, int) {
+	if fc.emoji.Len() == 0 {
+		return nil, 0
+	}
+	if rs[0] < textPresentation && (len(rs) < 2 || (rs[1] != emojiSelector && rs[1] != keycap)) {
+		return nil, 0
+	}
+	n := len(rs)
+	if n > maxEmojiRunes {
+		n = maxEmojiRunes
+	}
+	for ; n > 0; n-- {
+		var full, stripped []string
+		for _, r := range rs[:n] {
+			cp := fmt.Sprintf("%x", r)
+			full = append(full, cp)
+			if r != emojiSelector {
+				stripped = append(stripped, cp)
+			}
+		}
+		if img, ok := fc.emoji.Image(strings.Join(full, "-")); ok {
+			return img, n
+		}
+		if img, ok := fc.emoji.Image(strings.Join(stripped, "-")); ok && len(stripped) > 0 {
+			return img, n
+		}
+	}
+	return nil, 0
+}
+
+func (fc *fontChain) measure(text string) float64 {
+	w := 0.0
+	for _, r := range fc.runs(text) {
+		if r.emoji != nil {
+			w += fc.height()
+			continue
+		}
+		w += float64(font.MeasureString(fc.faces[r.face], r.text)) / 64
+	}
+	return w
+}
+
+// drawAnchored draws text the same way gg's DrawStringAnchored does, but run by run
+func (fc *fontChain) drawAnchored(dc *gg.Context, text string, x, y, ax, ay float64) {
+	h := fc.height()
+	x -= ax * fc.measure(text)
+	y += ay * h
+	for _, r := range fc.runs(text) {
+		if r.emoji != nil {
+			size := int(h)
+			img := transform.Resize(r.emoji, size, size, transform.Linear)
+			dc.DrawImage(img, int(x), int(y-h*0.85))
+			x += h
+			continue
+		}
+		dc.SetFontFace(fc.faces[r.face])
+		dc.DrawString(r.text, x, y)
+		x += float64(font.MeasureString(fc.faces[r.face], r.text)) / 64
+	}
+}
+
+// wordWrap splits text into lines no wider than width, only breaking at spaces
+func (fc *fontChain) wordWrap(text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line == "" {
+				line = word
+				continue
+			}
+			if fc.measure(line+" "+word) > width {
+				lines = append(lines, line)
+				line = word
+				continue
+			}
+			line += " " + word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// truncate shortens a line until it fits in width, ending it with an ellipsis
+func (fc *fontChain) truncate(line string, width float64) string {
+	if fc.measure(line) <= width {
+		return line
+	}
+	rs := []rune(strings.TrimSuffix(line, ellipsis))
+	cut := func(n int) string {
+		return strings.TrimRight(string(rs[:n]), " ") + ellipsis
+	}
+	// binary search for the most runes that fit, at least one is always cut off
+	lo, hi := 0, len(rs)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if fc.measure(cut(mid)) <= width {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return cut(lo)
+}
+
+// CheckFont draws text with f at a few sizes, returning an error instead of panicking if f can't be drawn with
+func CheckFont(f *truetype.Font, text string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("font can't be drawn: %v", r)
+		}
+	}()
+	// glyphs are loaded and rasterized even where they fall outside of the image, so it can be small
+	dc := gg.NewContext(256, 256)
+	for _, size := range []float64{12, 48, 96} {
+		face := truetype.NewFace(f, &truetype.Options{Size: size})
+		dc.SetFontFace(face)
+		dc.DrawString(text, 0, size)
+		font.MeasureString(face, text)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package card
+
+import (
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+
+	"github.com/ftqo/kirby/assets"
+)
+
+func TestFontChainRunsEmoji(t *testing.T) {
+	a := testAssets(t)
+	fc, err := newFontChain(a, "coolvetica", nil, nil)
+	if err != nil {
+		t.Fatalf("failed to make font chain: %v", err)
+	}
+
+	for _, c := range []struct {
+		name string
+		text string
+		// the text of each run, and whether it is drawn as an emoji
+		want  []string
+		emoji []bool
+	}{
+		{"single", "hi 👋", []string{"hi ", "👋"}, []bool{false, true}},
+		{"zero width joiner", "👩‍💻!", []string{"👩‍💻", "!"}, []bool{true, false}},
+		{"flag", "🇯🇵", []string{"🇯🇵"}, []bool{true}},
+		{"variation selector", "❤️", []string{"❤️"}, []bool{true}},
+		{"keycap", "#️⃣1", []string{"#️⃣", "1"}, []bool{true, false}},
+		{"text presentation", "#1 ©", []string{"#1 ©"}, []bool{false}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			runs := fc.runs(c.text)
+			var got []string
+			var emoji []bool
+			for _, r := range runs {
+				got = append(got, r.text)
+				emoji = append(emoji, r.emoji != nil)
+			}
+			if len(got) != len(c.want) {
+				t.Fatalf("got runs %q, want %q", got, c.want)
+			}
+			for i := range got {
+				if got[i] != c.want[i] || emoji[i] != c.emoji[i] {
+					t.Errorf("run %d is %q (emoji %t), want %q (emoji %t)", i, got[i], emoji[i], c.want[i], c.emoji[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFontChainTruncate(t *testing.T) {
+	a := testAssets(t)
+	fc, err := newFontChain(a, "coolvetica", nil, nil)
+	if err != nil {
+		t.Fatalf("failed to make font chain: %v", err)
+	}
+	fc.setSize(32)
+	defer fc.release()
+
+	width := 300.0
+	for _, c := range []struct {
+		name string
+		line string
+	}{
+		{"fits", "kirby"},
+		{"long", strings.Repeat("kirby ", 20)},
+		// long enough that cutting one rune at a time would take minutes
+		{"huge", strings.Repeat("k", 200000)},
+		{"already ellipsized", strings.Repeat("kirby", 20) + ellipsis},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got := fc.truncate(c.line, width)
+			if fc.measure(got) > width {
+				t.Errorf("truncated line is %.0f wide, over %.0f", fc.measure(got), width)
+			}
+			if fc.measure(c.line) <= width {
+				if got != c.line {
+					t.Errorf("line that fits was changed to %q", got)
+				}
+				return
+			}
+			if !strings.HasSuffix(got, ellipsis) {
+				t.Errorf("truncated line %q doesn't end with an ellipsis", got)
+			}
+			// keeping up to the next rune that isn't a space wouldn't have fit
+			full := []rune(strings.TrimSuffix(c.line, ellipsis))
+			n := len([]rune(strings.TrimSuffix(got, ellipsis))) + 1
+			for n < len(full) && full[n-1] == ' ' {
+				n++
+			}
+			if longer := string(full[:n]) + ellipsis; n < len(full) && fc.measure(longer) <= width {
+				t.Errorf("truncated to %q when %q fits too", got, longer)
+			}
+		})
+	}
+}
+
+// corruptFont is coolvetica with bytes overwritten so that it still parses, but panics when drawn
+func corruptFont(t *testing.T) *truetype.Font {
+	t.Helper()
+	raw, err := os.ReadFile("../assets/fonts/coolvetica.ttf")
+	if err != nil {
+		t.Fatalf("failed to read font: %v", err)
+	}
+	r := rand.New(rand.NewSource(20))
+	for i := 0; i < 20; i++ {
+		raw[r.Intn(len(raw))] = byte(r.Intn(256))
+	}
+	f, err := truetype.Parse(raw)
+	if err != nil {
+		t.Fatalf("corrupt font doesn't parse: %v", err)
+	}
+	return f
+}
+
+func TestCheckFont(t *testing.T) {
+	a := testAssets(t)
+	text := "kirby joined the server 0123456789"
+	if err := CheckFont(a.Fonts["coolvetica"], text); err != nil {
+		t.Errorf("embedded font failed the check: %v", err)
+	}
+	if err := CheckFont(corruptFont(t), text); err == nil {
+		t.Error("corrupt font passed the check")
+	}
+}
+
+func TestRenderCorruptFont(t *testing.T) {
+	a := testAssets(t)
+	w := testWelcome(a.Layouts[assets.DefaultLayout], a.Images["original"])
+	w.Font = corruptFont(t)
+	w.Faces = NewFaceCache(1 << 20)
+	_, err := w.Render(a, DefaultAvatar(512))
+	if err == nil {
+		t.Error("rendering with a corrupt font didn't fail")
+	}
+}
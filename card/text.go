@@ -0,0 +1,85 @@
+package card
+
+import (
+	"fmt"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+
+	"github.com/ftqo/kirby/assets"
+)
+
+const ellipsis = "..."
+
+// drawText draws a text layer, shrinking, wrapping, and finally truncating it to fit the layer's max width
+func drawText(dc *gg.Context, a *assets.Assets, ly assets.Layer, font *truetype.Font, faces *FaceCache, text string) (err error) {
+	fc, err := newFontChain(a, ly.Font, font, faces)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// a malformed font can parse fine and only panic once its glyphs are drawn, its faces aren't kept
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to draw text: %v", r)
+			return
+		}
+		fc.release()
+	}()
+	lines := fitText(fc, ly, text)
+
+	dc.SetColor(ly.Color)
+	lineHeight := fc.height() * ly.LineSpacing
+	top := ly.Y - ly.Anchor.Y*lineHeight*float64(len(lines))
+	for i, line := range lines {
+		fc.drawAnchored(dc, line, ly.X, top+(float64(i)+0.5)*lineHeight, ly.Anchor.X, 0.5)
+	}
+	return nil
+}
+
+// fitText sets the largest font size the text fits with and returns the lines to draw
+func fitText(fc *fontChain, ly assets.Layer, text string) []string {
+	if ly.MaxWidth <= 0 {
+		fc.setSize(ly.FontSize)
+		return []string{text}
+	}
+
+	for size := ly.FontSize; size >= ly.MinFontSize; size-- {
+		fc.setSize(size)
+		lines := wrap(fc, ly, text)
+		if len(lines) <= ly.MaxLines && fits(fc, ly, lines) {
+			return lines
+		}
+	}
+
+	// nothing fit, so cut off whatever doesn't at the smallest size
+	fc.setSize(ly.MinFontSize)
+	lines := wrap(fc, ly, text)
+	truncated := len(lines) > ly.MaxLines
+	if truncated {
+		lines = lines[:ly.MaxLines]
+	}
+	for i, line := range lines {
+		if truncated && i == len(lines)-1 {
+			lines[i] = fc.truncate(line+ellipsis, ly.MaxWidth)
+		} else {
+			lines[i] = fc.truncate(line, ly.MaxWidth)
+		}
+	}
+	return lines
+}
+
+func wrap(fc *fontChain, ly assets.Layer, text string) []string {
+	if ly.MaxLines <= 1 {
+		return []string{text}
+	}
+	return fc.wordWrap(text, ly.MaxWidth)
+}
+
+func fits(fc *fontChain, ly assets.Layer, lines []string) bool {
+	for _, line := range lines {
+		if fc.measure(line) > ly.MaxWidth {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package card
+
+import (
+	"testing"
+
+	"github.com/ftqo/kirby/assets"
+)
+
+func TestFitText(t *testing.T) {
+	a := testAssets(t)
+	fc, err := newFontChain(a, "coolvetica", nil, nil)
+	if err != nil {
+		t.Fatalf("failed to make font chain: %v", err)
+	}
+	defer fc.release()
+
+	// "kirby joined the server" is about 371 wide at 40 and 278 at 30
+	wrapped := assets.Layer{Font: "coolvetica", FontSize: 40, MinFontSize: 30, MaxWidth: 300, MaxLines: 2}
+	single := wrapped
+	single.MaxLines = 1
+
+	for _, c := range []struct {
+		name string
+		ly   assets.Layer
+		text string
+		want []string
+		size float64
+	}{
+		{"short", wrapped, "kirby", []string{"kirby"}, 40},
+		{"wrapping", wrapped, "kirby joined the server", []string{"kirby joined the", "server"}, 40},
+		{"shrinking", single, "kirby joined the server", []string{"kirby joined the server"}, 32},
+		{
+			"over max lines", wrapped, "kirby joined the server and brought lots of friends along",
+			[]string{"kirby joined the server", "and brought lots of..."}, 30,
+		},
+		{"unbreakable", wrapped, "kirbykirbykirbykirbykirbykirbykirby", []string{"kirbykirbykirbykirbykir..."}, 30},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got := fitText(fc, c.ly, c.text)
+			if len(got) != len(c.want) {
+				t.Fatalf("got lines %q, want %q", got, c.want)
+			}
+			for i := range got {
+				if got[i] != c.want[i] {
+					t.Errorf("line %d is %q, want %q", i, got[i], c.want[i])
+				}
+				if w := fc.measure(got[i]); w > c.ly.MaxWidth {
+					t.Errorf("line %d is %v wide, over the max width of %v", i, w, c.ly.MaxWidth)
+				}
+			}
+			if fc.size != c.size {
+				t.Errorf("chose size %v, want %v", fc.size, c.size)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package card
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/anthonynsimon/bild/transform"
+
+	"github.com/ftqo/kirby/assets"
+)
+
+// Theme overrides a layout's colors and avatar style, anything left nil is drawn as the layout has it
+type Theme struct {
+	// fill of rect layers
+	Overlay *color.NRGBA
+	// replaces the overlay's opacity, whichever color it ends up being
+	OverlayAlpha *uint8
+	Text         *color.NRGBA
+	// avatar border
+	Ring      *color.NRGBA
+	RingWidth *float64
+	// avatar shape and frame, left as the layout has them if empty
+	Shape string
+	Frame string
+	// draws a shadow under avatars even if the layout doesn't
+	Shadow bool
+}
+
+var (
+	lightText = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	darkText  = color.NRGBA{0x1e, 0x1a, 0x1e, 0xff}
+)
+
+const autoOverlayAlpha = 0xa0
+
+// AutoTheme picks colors that contrast with the dominant color of a background
+func AutoTheme(bg image.Image) Theme {
+	d := DominantColor(bg)
+	var text, overlay color.NRGBA
+	if luminance(d) < 0.55 {
+		// dark background, so light text over a darker overlay
+		text = lightText
+		overlay = mix(d, color.NRGBA{0, 0, 0, 0xff}, 0.65)
+	} else {
+		text = darkText
+		overlay = mix(d, color.NRGBA{0xff, 0xff, 0xff, 0xff}, 0.7)
+	}
+	overlay.A = autoOverlayAlpha
+	ring := text
+	return Theme{Overlay: &overlay, Text: &text, Ring: &ring}
+}
+
+// DominantColor returns the average of the most common group of similar colors in img
+func DominantColor(img image.Image) color.NRGBA {
+	small := transform.Resize(img, 32, 32, transform.Box)
+	type bucket struct {
+		r, g, b, n int
+	}
+	buckets := make(map[int]*bucket)
+	var best *bucket
+	for i := 0; i < len(small.Pix); i += 4 {
+		r, g, b := int(small.Pix[i]), int(small.Pix[i+1]), int(small.Pix[i+2])
+		key := r>>4<<8 | g>>4<<4 | b>>4
+		bk, ok := buckets[key]
+		if !ok {
+			bk = &bucket{}
+			buckets[key] = bk
+		}
+		bk.r += r
+		bk.g += g
+		bk.b += b
+		bk.n++
+		if best == nil || bk.n > best.n {
+			best = bk
+		}
+	}
+	if best == nil {
+		return color.NRGBA{0, 0, 0, 0xff}
+	}
+	return color.NRGBA{uint8(best.r / best.n), uint8(best.g / best.n), uint8(best.b / best.n), 0xff}
+}
+
+// luminance is the relative luminance of c from 0 to 1, ignoring gamma
+func luminance(c color.NRGBA) float64 {
+	return (0.2126*float64(c.R) + 0.7152*float64(c.G) + 0.0722*float64(c.B)) / 255
+}
+
+// mix moves a toward b by t
+func mix(a, b color.NRGBA, t float64) color.NRGBA {
+	m := func(x, y uint8) uint8 {
+		return uint8(float64(x)*(1-t) + float64(y)*t + 0.5)
+	}
+	return color.NRGBA{m(a.R, b.R), m(a.G, b.G), m(a.B, b.B), m(a.A, b.A)}
+}
+
+// apply returns a layer with the theme's colors in place of its own
+func (t Theme) apply(ly assets.Layer) assets.Layer {
+	switch ly.Type {
+	case "rect":
+		if t.Overlay != nil {
+			ly.Color.NRGBA = *t.Overlay
+		}
+		if t.OverlayAlpha != nil {
+			ly.Color.A = *t.OverlayAlpha
+		}
+	case "text":
+		if t.Text != nil {
+			ly.Color.NRGBA = *t.Text
+		}
+	case "avatar", "avatars":
+		if t.Ring != nil {
+			ly.Border.Color.NRGBA = *t.Ring
+		}
+		if t.RingWidth != nil {
+			ly.Border.Width = *t.RingWidth
+		}
+		if t.Shape != "" {
+			ly.Shape = t.Shape
+		}
+		if t.Frame != "" {
+			ly.Frame = t.Frame
+		}
+		if t.Shadow {
+			ly.Shadow = true
+		}
+	}
+	return ly
+}
@@ -0,0 +1,54 @@
+package card
+
+import (
+	"image"
+	"strconv"
+
+	"github.com/golang/freetype/truetype"
+
+	"github.com/ftqo/kirby/assets"
+)
+
+// Welcome is everything on a member's welcome card except their avatar, which is usually
+// fetched separately, so the same card can be drawn for any avatar
+type Welcome struct {
+	Layout *assets.Layout
+	// Background is only drawn if Base isn't set, see Data
+	Background image.Image
+	Base       image.Image
+	Theme      Theme
+	Font       *truetype.Font
+	Faces      *FaceCache
+
+	Title    string
+	Subtitle string
+	Nickname string
+	Username string
+	Guild    string
+	Members  int
+}
+
+// Data returns what Render needs to draw the card with avatar
+func (w Welcome) Data(avatar image.Image) Data {
+	return Data{
+		Background: w.Background,
+		Base:       w.Base,
+		Theme:      w.Theme,
+		Font:       w.Font,
+		Faces:      w.Faces,
+		Avatar:     avatar,
+		Texts: map[string]string{
+			"title":    w.Title,
+			"subtitle": w.Subtitle,
+			"nickname": w.Nickname,
+			"username": w.Username,
+			"guild":    w.Guild,
+			"members":  strconv.Itoa(w.Members),
+		},
+	}
+}
+
+// Render draws the card with avatar, it doesn't touch the network or the database so it is the same every time
+func (w Welcome) Render(a *assets.Assets, avatar image.Image) (image.Image, error) {
+	return Render(a, w.Layout, w.Data(avatar))
+}
@@ -0,0 +1,227 @@
+package card
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/disgoorg/log"
+
+	"github.com/ftqo/kirby/assets"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata with the current output")
+
+const (
+	// how far a channel can be off before a pixel counts as different, for small differences in floating point math between platforms
+	channelTolerance = 8
+	// how many pixels can be different before a card fails
+	maxDifferentPixels = 0.001
+)
+
+func testAssets(t *testing.T) *assets.Assets {
+	t.Helper()
+	l := log.New(0)
+	l.SetLevel(log.LevelError)
+	a, err := assets.GetAssets(l)
+	if err != nil {
+		t.Fatalf("failed to get assets: %v", err)
+	}
+	return a
+}
+
+// testWelcome is a representative card, with a title long enough to need shrinking in narrow layouts
+func testWelcome(l *assets.Layout, bg image.Image) Welcome {
+	return Welcome{
+		Layout:     l,
+		Background: bg,
+		Title:      "kirby joined the server",
+		Subtitle:   "you are our 1,234th member!",
+		Nickname:   "kirby",
+		Username:   "kirby#0000",
+		Guild:      "dream land",
+		Members:    1234,
+	}
+}
+
+func TestWelcomeGolden(t *testing.T) {
+	a := testAssets(t)
+
+	type golden struct {
+		name string
+		w    Welcome
+	}
+	var cases []golden
+	// every background with the default layout
+	for _, bg := range sortedKeys(a.Images) {
+		cases = append(cases, golden{
+			name: assets.DefaultLayout + "-" + bg,
+			w:    testWelcome(a.Layouts[assets.DefaultLayout], a.Images[bg]),
+		})
+	}
+	// and every other layout guilds can pick with one background
+	for _, name := range sortedKeys(a.Layouts) {
+		if a.Layouts[name].Internal || name == assets.DefaultLayout {
+			continue
+		}
+		cases = append(cases, golden{
+			name: name + "-original",
+			w:    testWelcome(a.Layouts[name], a.Images["original"]),
+		})
+	}
+	// and every avatar shape, with a shadow, and frame on the default layout
+	for _, shape := range assets.AvatarShapes {
+		w := testWelcome(a.Layouts[assets.DefaultLayout], a.Images["original"])
+		w.Theme = Theme{Shape: shape, Shadow: true}
+		cases = append(cases, golden{name: "shape-" + shape, w: w})
+	}
+	for _, frame := range sortedKeys(a.Frames) {
+		w := testWelcome(a.Layouts[assets.DefaultLayout], a.Images["original"])
+		w.Theme = Theme{Frame: frame}
+		cases = append(cases, golden{name: "frame-" + frame, w: w})
+	}
+
+	// and text that needs the fallback fonts and emoji
+	w := testWelcome(a.Layouts[assets.DefaultLayout], a.Images["original"])
+	w.Title = "カービィ 👋 joined 🇯🇵"
+	w.Subtitle = "👩‍💻 member #️⃣ 1,234 ❤️ ©"
+	cases = append(cases, golden{name: "text-emoji", w: w})
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.w.Render(a, DefaultAvatar(512))
+			if err != nil {
+				t.Fatalf("failed to render: %v", err)
+			}
+			path := filepath.Join("testdata", c.name+".png")
+			if *update {
+				writePNG(t, path, got)
+				return
+			}
+			want := readPNG(t, path)
+			if err := compareImages(got, want); err != nil {
+				// not t.TempDir, which is removed as soon as the test ends
+				actual := filepath.Join(os.TempDir(), "kirby-golden", c.name+".png")
+				writePNG(t, actual, got)
+				t.Errorf("%v, the new card is at %s, run go test ./card -update if the change is intended", err, actual)
+			}
+		})
+	}
+}
+
+func TestWelcomeRenderIsPure(t *testing.T) {
+	a := testAssets(t)
+	w := testWelcome(a.Layouts[assets.DefaultLayout], a.Images["original"])
+	first, err := w.Render(a, DefaultAvatar(512))
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	second, err := w.Render(a, DefaultAvatar(512))
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	if err := compareImages(first, second); err != nil {
+		t.Errorf("rendering the same card twice gave different images: %v", err)
+	}
+}
+
+func TestWelcomeRenderReusesFaces(t *testing.T) {
+	a := testAssets(t)
+	w := testWelcome(a.Layouts[assets.DefaultLayout], a.Images["original"])
+	want, err := w.Render(a, DefaultAvatar(512))
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	w.Faces = NewFaceCache(32 << 20)
+	for i := 0; i < 2; i++ {
+		got, err := w.Render(a, DefaultAvatar(512))
+		if err != nil {
+			t.Fatalf("failed to render: %v", err)
+		}
+		if err := compareImages(got, want); err != nil {
+			t.Errorf("render %d with cached faces differs from one without: %v", i, err)
+		}
+	}
+	if s := w.Faces.Stats(); s.Hits == 0 {
+		t.Errorf("second render made new faces, stats are %+v", s)
+	}
+}
+
+// compareImages fails if more than maxDifferentPixels of the pixels differ by more than channelTolerance
+func compareImages(got, want image.Image) error {
+	if got.Bounds() != want.Bounds() {
+		return fmt.Errorf("size is %v, want %v", got.Bounds(), want.Bounds())
+	}
+	b := got.Bounds()
+	different := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r1, g1, b1, a1 := got.At(x, y).RGBA()
+			r2, g2, b2, a2 := want.At(x, y).RGBA()
+			for _, d := range []int{diff(r1, r2), diff(g1, g2), diff(b1, b2), diff(a1, a2)} {
+				if d > channelTolerance {
+					different++
+					break
+				}
+			}
+		}
+	}
+	if ratio := float64(different) / float64(b.Dx()*b.Dy()); ratio > maxDifferentPixels {
+		return fmt.Errorf("%d pixels (%.2f%%) differ from the golden image", different, ratio*100)
+	}
+	return nil
+}
+
+// diff is the difference between two 16 bit channels, in 8 bits
+func diff(a, b uint32) int {
+	d := int(a>>8) - int(b>>8)
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func readPNG(t *testing.T, path string) image.Image {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open golden image, run go test ./card -update to create it: %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("failed to decode golden image: %v", err)
+	}
+	return img
+}
+
+func writePNG(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create %s: %v", path, err)
+	}
+	defer f.Close()
+	err = png.Encode(f, img)
+	if err != nil {
+		t.Fatalf("failed to encode %s: %v", path, err)
+	}
+}
@@ -22,7 +22,24 @@ type DBConfig struct {
 }
 
 type APIConfig struct {
-	Port int `yaml:"port"`
+	Host        string   `yaml:"host"`
+	Port        int      `yaml:"port"`
+	Token       string   `yaml:"token"`
+	AvatarHosts []string `yaml:"avatarHosts"`
+}
+
+type RenderConfig struct {
+	Workers          int    `yaml:"workers"`
+	MaxQueue         int    `yaml:"maxQueue"`
+	MaxQueuePerGuild int    `yaml:"maxQueuePerGuild"`
+	Overflow         string `yaml:"overflow"`
+	AvatarCacheMiB   int    `yaml:"avatarCacheMiB"`
+	LayerCacheMiB    int    `yaml:"layerCacheMiB"`
+	FontCacheMiB     int    `yaml:"fontCacheMiB"`
+	FaceCacheMiB     int    `yaml:"faceCacheMiB"`
+	Format           string `yaml:"format"`
+	Quality          int    `yaml:"quality"`
+	MaxUploadMiB     int    `yaml:"maxUploadMiB"`
 }
 
 type LogConfig struct {
@@ -38,6 +55,7 @@ type Config struct {
 	DBConfig      `yaml:"db"`
 	DiscordConfig `yaml:"discord"`
 	LogConfig     `yaml:"log"`
+	RenderConfig  `yaml:"render"`
 }
 
 func GetConfig() (Config, error) {
@@ -46,7 +64,7 @@ func GetConfig() (Config, error) {
 		return Config{}, fmt.Errorf("failed to read config file: %v", err)
 	}
 
-	c := Config{APIConfig{}, DBConfig{}, DiscordConfig{}, LogConfig{}}
+	c := Config{APIConfig{}, DBConfig{}, DiscordConfig{}, LogConfig{}, RenderConfig{}}
 	err = yaml.Unmarshal(b, &c)
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to unmarshal config into struct: %v", err)
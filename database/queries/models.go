@@ -4,19 +4,114 @@
 
 package queries
 
-import ()
+import (
+	"time"
+)
+
+type Goodbye struct {
+	GuildID          string
+	ChannelID        string
+	MessageType      string
+	MessageText      string
+	ImageName        string
+	ImageTitle       string
+	ImageSubtitle    string
+	EmbedTitle       string
+	EmbedDescription string
+	EmbedColor       int32
+	EmbedFooter      string
+	EmbedTimestamp   bool
+}
+
+type JoinRole struct {
+	GuildID      string
+	RoleID       string
+	DelaySeconds int32
+	SkipBots     bool
+}
 
 type KvPair struct {
 	K string
 	V string
 }
 
+type Milestone struct {
+	GuildID     string
+	ChannelID   string
+	Every       int32
+	Numbers     string
+	MessageText string
+}
+
+type ReachedMilestone struct {
+	GuildID string
+	Members int32
+}
+
 type Welcome struct {
+	GuildID          string
+	ChannelID        string
+	MessageType      string
+	MessageText      string
+	ImageName        string
+	ImageTitle       string
+	ImageSubtitle    string
+	EmbedTitle       string
+	EmbedDescription string
+	EmbedColor       int32
+	EmbedFooter      string
+	EmbedTimestamp   bool
+	LayoutName       string
+	Animated         bool
+	ImageFormat      string
+	ImageQuality     int32
+	OverlayColor     string
+	OverlayOpacity   int32
+	TextColor        string
+	RingColor        string
+	RingWidth        int32
+	FontName         string
+	BatchThreshold   int32
+	BatchWindow      int32
+	DmEnabled        bool
+	DmText           string
+	DmCard           bool
+	DmFallback       bool
+	Timezone         string
+	VariantMode      string
+	WaveButton       bool
+	AvatarShape      string
+	AvatarShadow     bool
+	AvatarFrame      string
+}
+
+type WelcomeButton struct {
+	GuildID string
+	Label   string
+	Url     string
+}
+
+type WelcomeFont struct {
+	GuildID string
+	Font    []byte
+}
+
+type WelcomeImage struct {
+	GuildID string
+	Image   []byte
+}
+
+type WelcomeVariant struct {
+	ID            int32
 	GuildID       string
-	ChannelID     string
-	MessageType   string
 	MessageText   string
-	ImageName     string
 	ImageTitle    string
 	ImageSubtitle string
+	Weight        int32
+}
+
+type WelcomeWave struct {
+	MessageID string
+	UserID    string
+	WavedAt   time.Time
 }
@@ -6,20 +6,87 @@ package queries
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
+	CountWelcomeWaves(ctx context.Context, messageID string) (int64, error)
+	DeleteGoodbye(ctx context.Context, guildID string) error
+	DeleteJoinRole(ctx context.Context, arg DeleteJoinRoleParams) (int64, error)
 	DeleteWelcome(ctx context.Context, guildID string) error
+	DeleteWelcomeButton(ctx context.Context, arg DeleteWelcomeButtonParams) (int64, error)
+	DeleteWelcomeFont(ctx context.Context, guildID string) error
+	DeleteWelcomeImage(ctx context.Context, guildID string) error
+	DeleteWelcomeVariant(ctx context.Context, arg DeleteWelcomeVariantParams) (int64, error)
+	DeleteWelcomeWavesBefore(ctx context.Context, wavedAt time.Time) (int64, error)
+	GetGoodbye(ctx context.Context, guildID string) (Goodbye, error)
+	GetJoinRoles(ctx context.Context, guildID string) ([]JoinRole, error)
+	GetMilestone(ctx context.Context, guildID string) (Milestone, error)
+	GetReachedMilestones(ctx context.Context, guildID string) ([]int32, error)
 	GetV(ctx context.Context, k string) (string, error)
 	GetWelcome(ctx context.Context, guildID string) (Welcome, error)
+	GetWelcomeButtons(ctx context.Context, guildID string) ([]WelcomeButton, error)
+	GetWelcomeFont(ctx context.Context, guildID string) ([]byte, error)
+	GetWelcomeImage(ctx context.Context, guildID string) ([]byte, error)
+	GetWelcomeVariants(ctx context.Context, guildID string) ([]WelcomeVariant, error)
+	InsertGoodbye(ctx context.Context, arg InsertGoodbyeParams) error
+	InsertMilestone(ctx context.Context, arg InsertMilestoneParams) error
+	InsertReachedMilestone(ctx context.Context, arg InsertReachedMilestoneParams) (int64, error)
 	InsertWelcome(ctx context.Context, arg InsertWelcomeParams) error
+	InsertWelcomeVariant(ctx context.Context, arg InsertWelcomeVariantParams) error
+	InsertWelcomeWave(ctx context.Context, arg InsertWelcomeWaveParams) (int64, error)
+	SetGoodbyeChannel(ctx context.Context, arg SetGoodbyeChannelParams) error
+	SetGoodbyeEmbedColor(ctx context.Context, arg SetGoodbyeEmbedColorParams) error
+	SetGoodbyeEmbedDescription(ctx context.Context, arg SetGoodbyeEmbedDescriptionParams) error
+	SetGoodbyeEmbedFooter(ctx context.Context, arg SetGoodbyeEmbedFooterParams) error
+	SetGoodbyeEmbedTimestamp(ctx context.Context, arg SetGoodbyeEmbedTimestampParams) error
+	SetGoodbyeEmbedTitle(ctx context.Context, arg SetGoodbyeEmbedTitleParams) error
+	SetGoodbyeImageName(ctx context.Context, arg SetGoodbyeImageNameParams) error
+	SetGoodbyeImageSubtitle(ctx context.Context, arg SetGoodbyeImageSubtitleParams) error
+	SetGoodbyeImageTitle(ctx context.Context, arg SetGoodbyeImageTitleParams) error
+	SetGoodbyeMessageText(ctx context.Context, arg SetGoodbyeMessageTextParams) error
+	SetGoodbyeMessageType(ctx context.Context, arg SetGoodbyeMessageTypeParams) error
+	SetMilestoneChannel(ctx context.Context, arg SetMilestoneChannelParams) error
+	SetMilestoneEvery(ctx context.Context, arg SetMilestoneEveryParams) error
+	SetMilestoneMessageText(ctx context.Context, arg SetMilestoneMessageTextParams) error
+	SetMilestoneNumbers(ctx context.Context, arg SetMilestoneNumbersParams) error
+	SetWelcomeAnimated(ctx context.Context, arg SetWelcomeAnimatedParams) error
+	SetWelcomeAvatarFrame(ctx context.Context, arg SetWelcomeAvatarFrameParams) error
+	SetWelcomeAvatarShadow(ctx context.Context, arg SetWelcomeAvatarShadowParams) error
+	SetWelcomeAvatarShape(ctx context.Context, arg SetWelcomeAvatarShapeParams) error
+	SetWelcomeBatch(ctx context.Context, arg SetWelcomeBatchParams) error
 	SetWelcomeChannel(ctx context.Context, arg SetWelcomeChannelParams) error
+	SetWelcomeDMCard(ctx context.Context, arg SetWelcomeDMCardParams) error
+	SetWelcomeDMEnabled(ctx context.Context, arg SetWelcomeDMEnabledParams) error
+	SetWelcomeDMFallback(ctx context.Context, arg SetWelcomeDMFallbackParams) error
+	SetWelcomeDMText(ctx context.Context, arg SetWelcomeDMTextParams) error
+	SetWelcomeEmbedColor(ctx context.Context, arg SetWelcomeEmbedColorParams) error
+	SetWelcomeEmbedDescription(ctx context.Context, arg SetWelcomeEmbedDescriptionParams) error
+	SetWelcomeEmbedFooter(ctx context.Context, arg SetWelcomeEmbedFooterParams) error
+	SetWelcomeEmbedTimestamp(ctx context.Context, arg SetWelcomeEmbedTimestampParams) error
+	SetWelcomeEmbedTitle(ctx context.Context, arg SetWelcomeEmbedTitleParams) error
+	SetWelcomeFontName(ctx context.Context, arg SetWelcomeFontNameParams) error
+	SetWelcomeImageFormat(ctx context.Context, arg SetWelcomeImageFormatParams) error
 	SetWelcomeImageName(ctx context.Context, arg SetWelcomeImageNameParams) error
+	SetWelcomeImageQuality(ctx context.Context, arg SetWelcomeImageQualityParams) error
 	SetWelcomeImageSubtitle(ctx context.Context, arg SetWelcomeImageSubtitleParams) error
 	SetWelcomeImageTitle(ctx context.Context, arg SetWelcomeImageTitleParams) error
+	SetWelcomeLayoutName(ctx context.Context, arg SetWelcomeLayoutNameParams) error
 	SetWelcomeMessageText(ctx context.Context, arg SetWelcomeMessageTextParams) error
 	SetWelcomeMessageType(ctx context.Context, arg SetWelcomeMessageTypeParams) error
+	SetWelcomeOverlayColor(ctx context.Context, arg SetWelcomeOverlayColorParams) error
+	SetWelcomeOverlayOpacity(ctx context.Context, arg SetWelcomeOverlayOpacityParams) error
+	SetWelcomeRingColor(ctx context.Context, arg SetWelcomeRingColorParams) error
+	SetWelcomeRingWidth(ctx context.Context, arg SetWelcomeRingWidthParams) error
+	SetWelcomeTextColor(ctx context.Context, arg SetWelcomeTextColorParams) error
+	SetWelcomeTimezone(ctx context.Context, arg SetWelcomeTimezoneParams) error
+	SetWelcomeVariantMode(ctx context.Context, arg SetWelcomeVariantModeParams) error
+	SetWelcomeWaveButton(ctx context.Context, arg SetWelcomeWaveButtonParams) error
+	UpsertJoinRole(ctx context.Context, arg UpsertJoinRoleParams) error
 	UpsertKV(ctx context.Context, arg UpsertKVParams) error
+	UpsertWelcomeButton(ctx context.Context, arg UpsertWelcomeButtonParams) error
+	UpsertWelcomeFont(ctx context.Context, arg UpsertWelcomeFontParams) error
+	UpsertWelcomeImage(ctx context.Context, arg UpsertWelcomeImageParams) error
 }
 
 var _ Querier = (*Queries)(nil)
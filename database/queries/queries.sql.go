@@ -7,8 +7,46 @@ package queries
 
 import (
 	"context"
+	"time"
 )
 
+const countWelcomeWaves = `-- name: CountWelcomeWaves :one
+SELECT COUNT(*) FROM welcome_waves WHERE message_id = $1
+`
+
+func (q *Queries) CountWelcomeWaves(ctx context.Context, messageID string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countWelcomeWaves, messageID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteGoodbye = `-- name: DeleteGoodbye :exec
+DELETE FROM goodbyes WHERE guild_id = $1
+`
+
+func (q *Queries) DeleteGoodbye(ctx context.Context, guildID string) error {
+	_, err := q.db.ExecContext(ctx, deleteGoodbye, guildID)
+	return err
+}
+
+const deleteJoinRole = `-- name: DeleteJoinRole :execrows
+DELETE FROM join_roles WHERE guild_id = $1 AND role_id = $2
+`
+
+type DeleteJoinRoleParams struct {
+	GuildID string
+	RoleID  string
+}
+
+func (q *Queries) DeleteJoinRole(ctx context.Context, arg DeleteJoinRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteJoinRole, arg.GuildID, arg.RoleID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteWelcome = `-- name: DeleteWelcome :exec
 DELETE FROM welcomes WHERE guild_id = $1
 `
@@ -18,6 +56,170 @@ func (q *Queries) DeleteWelcome(ctx context.Context, guildID string) error {
 	return err
 }
 
+const deleteWelcomeButton = `-- name: DeleteWelcomeButton :execrows
+DELETE FROM welcome_buttons WHERE guild_id = $1 AND label = $2
+`
+
+type DeleteWelcomeButtonParams struct {
+	GuildID string
+	Label   string
+}
+
+func (q *Queries) DeleteWelcomeButton(ctx context.Context, arg DeleteWelcomeButtonParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWelcomeButton, arg.GuildID, arg.Label)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteWelcomeFont = `-- name: DeleteWelcomeFont :exec
+DELETE FROM welcome_fonts WHERE guild_id = $1
+`
+
+func (q *Queries) DeleteWelcomeFont(ctx context.Context, guildID string) error {
+	_, err := q.db.ExecContext(ctx, deleteWelcomeFont, guildID)
+	return err
+}
+
+const deleteWelcomeImage = `-- name: DeleteWelcomeImage :exec
+DELETE FROM welcome_images WHERE guild_id = $1
+`
+
+func (q *Queries) DeleteWelcomeImage(ctx context.Context, guildID string) error {
+	_, err := q.db.ExecContext(ctx, deleteWelcomeImage, guildID)
+	return err
+}
+
+const deleteWelcomeVariant = `-- name: DeleteWelcomeVariant :execrows
+DELETE FROM welcome_variants WHERE guild_id = $1 AND id = $2
+`
+
+type DeleteWelcomeVariantParams struct {
+	GuildID string
+	ID      int32
+}
+
+func (q *Queries) DeleteWelcomeVariant(ctx context.Context, arg DeleteWelcomeVariantParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWelcomeVariant, arg.GuildID, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteWelcomeWavesBefore = `-- name: DeleteWelcomeWavesBefore :execrows
+DELETE FROM welcome_waves WHERE waved_at < $1
+`
+
+func (q *Queries) DeleteWelcomeWavesBefore(ctx context.Context, wavedAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteWelcomeWavesBefore, wavedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getGoodbye = `-- name: GetGoodbye :one
+SELECT guild_id, channel_id, message_type, message_text, image_name, image_title, image_subtitle, embed_title, embed_description, embed_color, embed_footer, embed_timestamp FROM goodbyes WHERE guild_id = $1
+`
+
+func (q *Queries) GetGoodbye(ctx context.Context, guildID string) (Goodbye, error) {
+	row := q.db.QueryRowContext(ctx, getGoodbye, guildID)
+	var i Goodbye
+	err := row.Scan(
+		&i.GuildID,
+		&i.ChannelID,
+		&i.MessageType,
+		&i.MessageText,
+		&i.ImageName,
+		&i.ImageTitle,
+		&i.ImageSubtitle,
+		&i.EmbedTitle,
+		&i.EmbedDescription,
+		&i.EmbedColor,
+		&i.EmbedFooter,
+		&i.EmbedTimestamp,
+	)
+	return i, err
+}
+
+const getJoinRoles = `-- name: GetJoinRoles :many
+SELECT guild_id, role_id, delay_seconds, skip_bots FROM join_roles WHERE guild_id = $1 ORDER BY role_id
+`
+
+func (q *Queries) GetJoinRoles(ctx context.Context, guildID string) ([]JoinRole, error) {
+	rows, err := q.db.QueryContext(ctx, getJoinRoles, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []JoinRole
+	for rows.Next() {
+		var i JoinRole
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.RoleID,
+			&i.DelaySeconds,
+			&i.SkipBots,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMilestone = `-- name: GetMilestone :one
+SELECT guild_id, channel_id, every, numbers, message_text FROM milestones WHERE guild_id = $1
+`
+
+func (q *Queries) GetMilestone(ctx context.Context, guildID string) (Milestone, error) {
+	row := q.db.QueryRowContext(ctx, getMilestone, guildID)
+	var i Milestone
+	err := row.Scan(
+		&i.GuildID,
+		&i.ChannelID,
+		&i.Every,
+		&i.Numbers,
+		&i.MessageText,
+	)
+	return i, err
+}
+
+const getReachedMilestones = `-- name: GetReachedMilestones :many
+SELECT members FROM reached_milestones WHERE guild_id = $1 ORDER BY members DESC
+`
+
+func (q *Queries) GetReachedMilestones(ctx context.Context, guildID string) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, getReachedMilestones, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var members int32
+		if err := rows.Scan(&members); err != nil {
+			return nil, err
+		}
+		items = append(items, members)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getV = `-- name: GetV :one
 SELECT v FROM kv_pairs WHERE k = $1
 `
@@ -30,7 +232,7 @@ func (q *Queries) GetV(ctx context.Context, k string) (string, error) {
 }
 
 const getWelcome = `-- name: GetWelcome :one
-SELECT guild_id, channel_id, message_type, message_text, image_name, image_title, image_subtitle FROM welcomes WHERE guild_id = $1
+SELECT guild_id, channel_id, message_type, message_text, image_name, image_title, image_subtitle, embed_title, embed_description, embed_color, embed_footer, embed_timestamp, layout_name, animated, image_format, image_quality, overlay_color, overlay_opacity, text_color, ring_color, ring_width, font_name, batch_threshold, batch_window, dm_enabled, dm_text, dm_card, dm_fallback, timezone, variant_mode, wave_button, avatar_shape, avatar_shadow, avatar_frame FROM welcomes WHERE guild_id = $1
 `
 
 func (q *Queries) GetWelcome(ctx context.Context, guildID string) (Welcome, error) {
@@ -44,24 +246,254 @@ func (q *Queries) GetWelcome(ctx context.Context, guildID string) (Welcome, erro
 		&i.ImageName,
 		&i.ImageTitle,
 		&i.ImageSubtitle,
+		&i.EmbedTitle,
+		&i.EmbedDescription,
+		&i.EmbedColor,
+		&i.EmbedFooter,
+		&i.EmbedTimestamp,
+		&i.LayoutName,
+		&i.Animated,
+		&i.ImageFormat,
+		&i.ImageQuality,
+		&i.OverlayColor,
+		&i.OverlayOpacity,
+		&i.TextColor,
+		&i.RingColor,
+		&i.RingWidth,
+		&i.FontName,
+		&i.BatchThreshold,
+		&i.BatchWindow,
+		&i.DmEnabled,
+		&i.DmText,
+		&i.DmCard,
+		&i.DmFallback,
+		&i.Timezone,
+		&i.VariantMode,
+		&i.WaveButton,
+		&i.AvatarShape,
+		&i.AvatarShadow,
+		&i.AvatarFrame,
 	)
 	return i, err
 }
 
+const getWelcomeButtons = `-- name: GetWelcomeButtons :many
+SELECT guild_id, label, url FROM welcome_buttons WHERE guild_id = $1 ORDER BY label
+`
+
+func (q *Queries) GetWelcomeButtons(ctx context.Context, guildID string) ([]WelcomeButton, error) {
+	rows, err := q.db.QueryContext(ctx, getWelcomeButtons, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WelcomeButton
+	for rows.Next() {
+		var i WelcomeButton
+		if err := rows.Scan(
+			&i.GuildID,
+			&i.Label,
+			&i.Url,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWelcomeFont = `-- name: GetWelcomeFont :one
+SELECT font FROM welcome_fonts WHERE guild_id = $1
+`
+
+func (q *Queries) GetWelcomeFont(ctx context.Context, guildID string) ([]byte, error) {
+	row := q.db.QueryRowContext(ctx, getWelcomeFont, guildID)
+	var font []byte
+	err := row.Scan(&font)
+	return font, err
+}
+
+const getWelcomeImage = `-- name: GetWelcomeImage :one
+SELECT image FROM welcome_images WHERE guild_id = $1
+`
+
+func (q *Queries) GetWelcomeImage(ctx context.Context, guildID string) ([]byte, error) {
+	row := q.db.QueryRowContext(ctx, getWelcomeImage, guildID)
+	var image []byte
+	err := row.Scan(&image)
+	return image, err
+}
+
+const getWelcomeVariants = `-- name: GetWelcomeVariants :many
+SELECT id, guild_id, message_text, image_title, image_subtitle, weight FROM welcome_variants WHERE guild_id = $1 ORDER BY id
+`
+
+func (q *Queries) GetWelcomeVariants(ctx context.Context, guildID string) ([]WelcomeVariant, error) {
+	rows, err := q.db.QueryContext(ctx, getWelcomeVariants, guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WelcomeVariant
+	for rows.Next() {
+		var i WelcomeVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.GuildID,
+			&i.MessageText,
+			&i.ImageTitle,
+			&i.ImageSubtitle,
+			&i.Weight,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertGoodbye = `-- name: InsertGoodbye :exec
+INSERT INTO goodbyes (guild_id, channel_id, message_type, message_text, image_name, image_title, image_subtitle,
+		embed_title, embed_description, embed_color, embed_footer, embed_timestamp)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	ON CONFLICT (guild_id) DO NOTHING
+`
+
+type InsertGoodbyeParams struct {
+	GuildID          string
+	ChannelID        string
+	MessageType      string
+	MessageText      string
+	ImageName        string
+	ImageTitle       string
+	ImageSubtitle    string
+	EmbedTitle       string
+	EmbedDescription string
+	EmbedColor       int32
+	EmbedFooter      string
+	EmbedTimestamp   bool
+}
+
+func (q *Queries) InsertGoodbye(ctx context.Context, arg InsertGoodbyeParams) error {
+	_, err := q.db.ExecContext(ctx, insertGoodbye,
+		arg.GuildID,
+		arg.ChannelID,
+		arg.MessageType,
+		arg.MessageText,
+		arg.ImageName,
+		arg.ImageTitle,
+		arg.ImageSubtitle,
+		arg.EmbedTitle,
+		arg.EmbedDescription,
+		arg.EmbedColor,
+		arg.EmbedFooter,
+		arg.EmbedTimestamp,
+	)
+	return err
+}
+
+const insertMilestone = `-- name: InsertMilestone :exec
+INSERT INTO milestones (guild_id, channel_id, every, numbers, message_text)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (guild_id) DO NOTHING
+`
+
+type InsertMilestoneParams struct {
+	GuildID     string
+	ChannelID   string
+	Every       int32
+	Numbers     string
+	MessageText string
+}
+
+func (q *Queries) InsertMilestone(ctx context.Context, arg InsertMilestoneParams) error {
+	_, err := q.db.ExecContext(ctx, insertMilestone,
+		arg.GuildID,
+		arg.ChannelID,
+		arg.Every,
+		arg.Numbers,
+		arg.MessageText,
+	)
+	return err
+}
+
+const insertReachedMilestone = `-- name: InsertReachedMilestone :execrows
+INSERT INTO reached_milestones (guild_id, members)
+	VALUES ($1, $2)
+	ON CONFLICT (guild_id, members) DO NOTHING
+`
+
+type InsertReachedMilestoneParams struct {
+	GuildID string
+	Members int32
+}
+
+func (q *Queries) InsertReachedMilestone(ctx context.Context, arg InsertReachedMilestoneParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertReachedMilestone, arg.GuildID, arg.Members)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const insertWelcome = `-- name: InsertWelcome :exec
-INSERT INTO welcomes (guild_id, channel_id, message_type, message_text, image_name, image_title, image_subtitle)
-	VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO welcomes (guild_id, channel_id, message_type, message_text, image_name, image_title, image_subtitle,
+		embed_title, embed_description, embed_color, embed_footer, embed_timestamp, layout_name, animated,
+		image_format, image_quality, overlay_color, overlay_opacity, text_color, ring_color, ring_width, font_name,
+		batch_threshold, batch_window, dm_enabled, dm_text, dm_card, dm_fallback, timezone,
+		variant_mode, wave_button, avatar_shape, avatar_shadow, avatar_frame)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24,
+		$25, $26, $27, $28, $29, $30, $31, $32, $33, $34)
 	ON CONFLICT (guild_id) DO NOTHING
 `
 
 type InsertWelcomeParams struct {
-	GuildID       string
-	ChannelID     string
-	MessageType   string
-	MessageText   string
-	ImageName     string
-	ImageTitle    string
-	ImageSubtitle string
+	GuildID          string
+	ChannelID        string
+	MessageType      string
+	MessageText      string
+	ImageName        string
+	ImageTitle       string
+	ImageSubtitle    string
+	EmbedTitle       string
+	EmbedDescription string
+	EmbedColor       int32
+	EmbedFooter      string
+	EmbedTimestamp   bool
+	LayoutName       string
+	Animated         bool
+	ImageFormat      string
+	ImageQuality     int32
+	OverlayColor     string
+	OverlayOpacity   int32
+	TextColor        string
+	RingColor        string
+	RingWidth        int32
+	FontName         string
+	BatchThreshold   int32
+	BatchWindow      int32
+	DmEnabled        bool
+	DmText           string
+	DmCard           bool
+	DmFallback       bool
+	Timezone         string
+	VariantMode      string
+	WaveButton       bool
+	AvatarShape      string
+	AvatarShadow     bool
+	AvatarFrame      string
 }
 
 func (q *Queries) InsertWelcome(ctx context.Context, arg InsertWelcomeParams) error {
@@ -73,6 +505,361 @@ func (q *Queries) InsertWelcome(ctx context.Context, arg InsertWelcomeParams) er
 		arg.ImageName,
 		arg.ImageTitle,
 		arg.ImageSubtitle,
+		arg.EmbedTitle,
+		arg.EmbedDescription,
+		arg.EmbedColor,
+		arg.EmbedFooter,
+		arg.EmbedTimestamp,
+		arg.LayoutName,
+		arg.Animated,
+		arg.ImageFormat,
+		arg.ImageQuality,
+		arg.OverlayColor,
+		arg.OverlayOpacity,
+		arg.TextColor,
+		arg.RingColor,
+		arg.RingWidth,
+		arg.FontName,
+		arg.BatchThreshold,
+		arg.BatchWindow,
+		arg.DmEnabled,
+		arg.DmText,
+		arg.DmCard,
+		arg.DmFallback,
+		arg.Timezone,
+		arg.VariantMode,
+		arg.WaveButton,
+		arg.AvatarShape,
+		arg.AvatarShadow,
+		arg.AvatarFrame,
+	)
+	return err
+}
+
+const insertWelcomeVariant = `-- name: InsertWelcomeVariant :exec
+INSERT INTO welcome_variants (guild_id, message_text, image_title, image_subtitle, weight)
+	VALUES ($1, $2, $3, $4, $5)
+`
+
+type InsertWelcomeVariantParams struct {
+	GuildID       string
+	MessageText   string
+	ImageTitle    string
+	ImageSubtitle string
+	Weight        int32
+}
+
+func (q *Queries) InsertWelcomeVariant(ctx context.Context, arg InsertWelcomeVariantParams) error {
+	_, err := q.db.ExecContext(ctx, insertWelcomeVariant,
+		arg.GuildID,
+		arg.MessageText,
+		arg.ImageTitle,
+		arg.ImageSubtitle,
+		arg.Weight,
+	)
+	return err
+}
+
+const insertWelcomeWave = `-- name: InsertWelcomeWave :execrows
+INSERT INTO welcome_waves (message_id, user_id)
+	VALUES ($1, $2)
+	ON CONFLICT (message_id, user_id) DO NOTHING
+`
+
+type InsertWelcomeWaveParams struct {
+	MessageID string
+	UserID    string
+}
+
+func (q *Queries) InsertWelcomeWave(ctx context.Context, arg InsertWelcomeWaveParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, insertWelcomeWave, arg.MessageID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setGoodbyeChannel = `-- name: SetGoodbyeChannel :exec
+UPDATE goodbyes SET channel_id = $1 WHERE guild_id = $2
+`
+
+type SetGoodbyeChannelParams struct {
+	ChannelID string
+	GuildID   string
+}
+
+func (q *Queries) SetGoodbyeChannel(ctx context.Context, arg SetGoodbyeChannelParams) error {
+	_, err := q.db.ExecContext(ctx, setGoodbyeChannel, arg.ChannelID, arg.GuildID)
+	return err
+}
+
+const setGoodbyeEmbedColor = `-- name: SetGoodbyeEmbedColor :exec
+UPDATE goodbyes SET embed_color = $1 WHERE guild_id = $2
+`
+
+type SetGoodbyeEmbedColorParams struct {
+	EmbedColor int32
+	GuildID    string
+}
+
+func (q *Queries) SetGoodbyeEmbedColor(ctx context.Context, arg SetGoodbyeEmbedColorParams) error {
+	_, err := q.db.ExecContext(ctx, setGoodbyeEmbedColor, arg.EmbedColor, arg.GuildID)
+	return err
+}
+
+const setGoodbyeEmbedDescription = `-- name: SetGoodbyeEmbedDescription :exec
+UPDATE goodbyes SET embed_description = $1 WHERE guild_id = $2
+`
+
+type SetGoodbyeEmbedDescriptionParams struct {
+	EmbedDescription string
+	GuildID          string
+}
+
+func (q *Queries) SetGoodbyeEmbedDescription(ctx context.Context, arg SetGoodbyeEmbedDescriptionParams) error {
+	_, err := q.db.ExecContext(ctx, setGoodbyeEmbedDescription, arg.EmbedDescription, arg.GuildID)
+	return err
+}
+
+const setGoodbyeEmbedFooter = `-- name: SetGoodbyeEmbedFooter :exec
+UPDATE goodbyes SET embed_footer = $1 WHERE guild_id = $2
+`
+
+type SetGoodbyeEmbedFooterParams struct {
+	EmbedFooter string
+	GuildID     string
+}
+
+func (q *Queries) SetGoodbyeEmbedFooter(ctx context.Context, arg SetGoodbyeEmbedFooterParams) error {
+	_, err := q.db.ExecContext(ctx, setGoodbyeEmbedFooter, arg.EmbedFooter, arg.GuildID)
+	return err
+}
+
+const setGoodbyeEmbedTimestamp = `-- name: SetGoodbyeEmbedTimestamp :exec
+UPDATE goodbyes SET embed_timestamp = $1 WHERE guild_id = $2
+`
+
+type SetGoodbyeEmbedTimestampParams struct {
+	EmbedTimestamp bool
+	GuildID        string
+}
+
+func (q *Queries) SetGoodbyeEmbedTimestamp(ctx context.Context, arg SetGoodbyeEmbedTimestampParams) error {
+	_, err := q.db.ExecContext(ctx, setGoodbyeEmbedTimestamp, arg.EmbedTimestamp, arg.GuildID)
+	return err
+}
+
+const setGoodbyeEmbedTitle = `-- name: SetGoodbyeEmbedTitle :exec
+UPDATE goodbyes SET embed_title = $1 WHERE guild_id = $2
+`
+
+type SetGoodbyeEmbedTitleParams struct {
+	EmbedTitle string
+	GuildID    string
+}
+
+func (q *Queries) SetGoodbyeEmbedTitle(ctx context.Context, arg SetGoodbyeEmbedTitleParams) error {
+	_, err := q.db.ExecContext(ctx, setGoodbyeEmbedTitle, arg.EmbedTitle, arg.GuildID)
+	return err
+}
+
+const setGoodbyeImageName = `-- name: SetGoodbyeImageName :exec
+UPDATE goodbyes SET image_name = $1 WHERE guild_id = $2
+`
+
+type SetGoodbyeImageNameParams struct {
+	ImageName string
+	GuildID   string
+}
+
+func (q *Queries) SetGoodbyeImageName(ctx context.Context, arg SetGoodbyeImageNameParams) error {
+	_, err := q.db.ExecContext(ctx, setGoodbyeImageName, arg.ImageName, arg.GuildID)
+	return err
+}
+
+const setGoodbyeImageSubtitle = `-- name: SetGoodbyeImageSubtitle :exec
+UPDATE goodbyes SET image_subtitle = $1 WHERE guild_id = $2
+`
+
+type SetGoodbyeImageSubtitleParams struct {
+	ImageSubtitle string
+	GuildID       string
+}
+
+func (q *Queries) SetGoodbyeImageSubtitle(ctx context.Context, arg SetGoodbyeImageSubtitleParams) error {
+	_, err := q.db.ExecContext(ctx, setGoodbyeImageSubtitle, arg.ImageSubtitle, arg.GuildID)
+	return err
+}
+
+const setGoodbyeImageTitle = `-- name: SetGoodbyeImageTitle :exec
+UPDATE goodbyes SET image_title = $1 WHERE guild_id = $2
+`
+
+type SetGoodbyeImageTitleParams struct {
+	ImageTitle string
+	GuildID    string
+}
+
+func (q *Queries) SetGoodbyeImageTitle(ctx context.Context, arg SetGoodbyeImageTitleParams) error {
+	_, err := q.db.ExecContext(ctx, setGoodbyeImageTitle, arg.ImageTitle, arg.GuildID)
+	return err
+}
+
+const setGoodbyeMessageText = `-- name: SetGoodbyeMessageText :exec
+UPDATE goodbyes SET message_text = $1 WHERE guild_id = $2
+`
+
+type SetGoodbyeMessageTextParams struct {
+	MessageText string
+	GuildID     string
+}
+
+func (q *Queries) SetGoodbyeMessageText(ctx context.Context, arg SetGoodbyeMessageTextParams) error {
+	_, err := q.db.ExecContext(ctx, setGoodbyeMessageText, arg.MessageText, arg.GuildID)
+	return err
+}
+
+const setGoodbyeMessageType = `-- name: SetGoodbyeMessageType :exec
+UPDATE goodbyes SET message_type = $1 WHERE guild_id = $2
+`
+
+type SetGoodbyeMessageTypeParams struct {
+	MessageType string
+	GuildID     string
+}
+
+func (q *Queries) SetGoodbyeMessageType(ctx context.Context, arg SetGoodbyeMessageTypeParams) error {
+	_, err := q.db.ExecContext(ctx, setGoodbyeMessageType, arg.MessageType, arg.GuildID)
+	return err
+}
+
+const setMilestoneChannel = `-- name: SetMilestoneChannel :exec
+UPDATE milestones SET channel_id = $1 WHERE guild_id = $2
+`
+
+type SetMilestoneChannelParams struct {
+	ChannelID string
+	GuildID   string
+}
+
+func (q *Queries) SetMilestoneChannel(ctx context.Context, arg SetMilestoneChannelParams) error {
+	_, err := q.db.ExecContext(ctx, setMilestoneChannel, arg.ChannelID, arg.GuildID)
+	return err
+}
+
+const setMilestoneEvery = `-- name: SetMilestoneEvery :exec
+UPDATE milestones SET every = $1 WHERE guild_id = $2
+`
+
+type SetMilestoneEveryParams struct {
+	Every   int32
+	GuildID string
+}
+
+func (q *Queries) SetMilestoneEvery(ctx context.Context, arg SetMilestoneEveryParams) error {
+	_, err := q.db.ExecContext(ctx, setMilestoneEvery, arg.Every, arg.GuildID)
+	return err
+}
+
+const setMilestoneMessageText = `-- name: SetMilestoneMessageText :exec
+UPDATE milestones SET message_text = $1 WHERE guild_id = $2
+`
+
+type SetMilestoneMessageTextParams struct {
+	MessageText string
+	GuildID     string
+}
+
+func (q *Queries) SetMilestoneMessageText(ctx context.Context, arg SetMilestoneMessageTextParams) error {
+	_, err := q.db.ExecContext(ctx, setMilestoneMessageText, arg.MessageText, arg.GuildID)
+	return err
+}
+
+const setMilestoneNumbers = `-- name: SetMilestoneNumbers :exec
+UPDATE milestones SET numbers = $1 WHERE guild_id = $2
+`
+
+type SetMilestoneNumbersParams struct {
+	Numbers string
+	GuildID string
+}
+
+func (q *Queries) SetMilestoneNumbers(ctx context.Context, arg SetMilestoneNumbersParams) error {
+	_, err := q.db.ExecContext(ctx, setMilestoneNumbers, arg.Numbers, arg.GuildID)
+	return err
+}
+
+const setWelcomeAnimated = `-- name: SetWelcomeAnimated :exec
+UPDATE welcomes SET animated = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeAnimatedParams struct {
+	Animated bool
+	GuildID  string
+}
+
+func (q *Queries) SetWelcomeAnimated(ctx context.Context, arg SetWelcomeAnimatedParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeAnimated, arg.Animated, arg.GuildID)
+	return err
+}
+
+const setWelcomeAvatarFrame = `-- name: SetWelcomeAvatarFrame :exec
+UPDATE welcomes SET avatar_frame = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeAvatarFrameParams struct {
+	AvatarFrame string
+	GuildID     string
+}
+
+func (q *Queries) SetWelcomeAvatarFrame(ctx context.Context, arg SetWelcomeAvatarFrameParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeAvatarFrame, arg.AvatarFrame, arg.GuildID)
+	return err
+}
+
+const setWelcomeAvatarShadow = `-- name: SetWelcomeAvatarShadow :exec
+UPDATE welcomes SET avatar_shadow = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeAvatarShadowParams struct {
+	AvatarShadow bool
+	GuildID      string
+}
+
+func (q *Queries) SetWelcomeAvatarShadow(ctx context.Context, arg SetWelcomeAvatarShadowParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeAvatarShadow, arg.AvatarShadow, arg.GuildID)
+	return err
+}
+
+const setWelcomeAvatarShape = `-- name: SetWelcomeAvatarShape :exec
+UPDATE welcomes SET avatar_shape = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeAvatarShapeParams struct {
+	AvatarShape string
+	GuildID     string
+}
+
+func (q *Queries) SetWelcomeAvatarShape(ctx context.Context, arg SetWelcomeAvatarShapeParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeAvatarShape, arg.AvatarShape, arg.GuildID)
+	return err
+}
+
+const setWelcomeBatch = `-- name: SetWelcomeBatch :exec
+UPDATE welcomes SET batch_threshold = $1, batch_window = $2 WHERE guild_id = $3
+`
+
+type SetWelcomeBatchParams struct {
+	BatchThreshold int32
+	BatchWindow    int32
+	GuildID        string
+}
+
+func (q *Queries) SetWelcomeBatch(ctx context.Context, arg SetWelcomeBatchParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeBatch,
+		arg.BatchThreshold,
+		arg.BatchWindow,
+		arg.GuildID,
 	)
 	return err
 }
@@ -91,6 +878,160 @@ func (q *Queries) SetWelcomeChannel(ctx context.Context, arg SetWelcomeChannelPa
 	return err
 }
 
+const setWelcomeDMCard = `-- name: SetWelcomeDMCard :exec
+UPDATE welcomes SET dm_card = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeDMCardParams struct {
+	DmCard  bool
+	GuildID string
+}
+
+func (q *Queries) SetWelcomeDMCard(ctx context.Context, arg SetWelcomeDMCardParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeDMCard, arg.DmCard, arg.GuildID)
+	return err
+}
+
+const setWelcomeDMEnabled = `-- name: SetWelcomeDMEnabled :exec
+UPDATE welcomes SET dm_enabled = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeDMEnabledParams struct {
+	DmEnabled bool
+	GuildID   string
+}
+
+func (q *Queries) SetWelcomeDMEnabled(ctx context.Context, arg SetWelcomeDMEnabledParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeDMEnabled, arg.DmEnabled, arg.GuildID)
+	return err
+}
+
+const setWelcomeDMFallback = `-- name: SetWelcomeDMFallback :exec
+UPDATE welcomes SET dm_fallback = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeDMFallbackParams struct {
+	DmFallback bool
+	GuildID    string
+}
+
+func (q *Queries) SetWelcomeDMFallback(ctx context.Context, arg SetWelcomeDMFallbackParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeDMFallback, arg.DmFallback, arg.GuildID)
+	return err
+}
+
+const setWelcomeDMText = `-- name: SetWelcomeDMText :exec
+UPDATE welcomes SET dm_text = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeDMTextParams struct {
+	DmText  string
+	GuildID string
+}
+
+func (q *Queries) SetWelcomeDMText(ctx context.Context, arg SetWelcomeDMTextParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeDMText, arg.DmText, arg.GuildID)
+	return err
+}
+
+const setWelcomeEmbedColor = `-- name: SetWelcomeEmbedColor :exec
+UPDATE welcomes SET embed_color = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeEmbedColorParams struct {
+	EmbedColor int32
+	GuildID    string
+}
+
+func (q *Queries) SetWelcomeEmbedColor(ctx context.Context, arg SetWelcomeEmbedColorParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeEmbedColor, arg.EmbedColor, arg.GuildID)
+	return err
+}
+
+const setWelcomeEmbedDescription = `-- name: SetWelcomeEmbedDescription :exec
+UPDATE welcomes SET embed_description = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeEmbedDescriptionParams struct {
+	EmbedDescription string
+	GuildID          string
+}
+
+func (q *Queries) SetWelcomeEmbedDescription(ctx context.Context, arg SetWelcomeEmbedDescriptionParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeEmbedDescription, arg.EmbedDescription, arg.GuildID)
+	return err
+}
+
+const setWelcomeEmbedFooter = `-- name: SetWelcomeEmbedFooter :exec
+UPDATE welcomes SET embed_footer = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeEmbedFooterParams struct {
+	EmbedFooter string
+	GuildID     string
+}
+
+func (q *Queries) SetWelcomeEmbedFooter(ctx context.Context, arg SetWelcomeEmbedFooterParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeEmbedFooter, arg.EmbedFooter, arg.GuildID)
+	return err
+}
+
+const setWelcomeEmbedTimestamp = `-- name: SetWelcomeEmbedTimestamp :exec
+UPDATE welcomes SET embed_timestamp = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeEmbedTimestampParams struct {
+	EmbedTimestamp bool
+	GuildID        string
+}
+
+func (q *Queries) SetWelcomeEmbedTimestamp(ctx context.Context, arg SetWelcomeEmbedTimestampParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeEmbedTimestamp, arg.EmbedTimestamp, arg.GuildID)
+	return err
+}
+
+const setWelcomeEmbedTitle = `-- name: SetWelcomeEmbedTitle :exec
+UPDATE welcomes SET embed_title = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeEmbedTitleParams struct {
+	EmbedTitle string
+	GuildID    string
+}
+
+func (q *Queries) SetWelcomeEmbedTitle(ctx context.Context, arg SetWelcomeEmbedTitleParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeEmbedTitle, arg.EmbedTitle, arg.GuildID)
+	return err
+}
+
+const setWelcomeFontName = `-- name: SetWelcomeFontName :exec
+UPDATE welcomes SET font_name = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeFontNameParams struct {
+	FontName string
+	GuildID  string
+}
+
+func (q *Queries) SetWelcomeFontName(ctx context.Context, arg SetWelcomeFontNameParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeFontName, arg.FontName, arg.GuildID)
+	return err
+}
+
+const setWelcomeImageFormat = `-- name: SetWelcomeImageFormat :exec
+UPDATE welcomes SET image_format = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeImageFormatParams struct {
+	ImageFormat string
+	GuildID     string
+}
+
+func (q *Queries) SetWelcomeImageFormat(ctx context.Context, arg SetWelcomeImageFormatParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeImageFormat, arg.ImageFormat, arg.GuildID)
+	return err
+}
+
 const setWelcomeImageName = `-- name: SetWelcomeImageName :exec
 UPDATE welcomes SET image_name = $1 WHERE guild_id = $2
 `
@@ -105,6 +1046,20 @@ func (q *Queries) SetWelcomeImageName(ctx context.Context, arg SetWelcomeImageNa
 	return err
 }
 
+const setWelcomeImageQuality = `-- name: SetWelcomeImageQuality :exec
+UPDATE welcomes SET image_quality = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeImageQualityParams struct {
+	ImageQuality int32
+	GuildID      string
+}
+
+func (q *Queries) SetWelcomeImageQuality(ctx context.Context, arg SetWelcomeImageQualityParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeImageQuality, arg.ImageQuality, arg.GuildID)
+	return err
+}
+
 const setWelcomeImageSubtitle = `-- name: SetWelcomeImageSubtitle :exec
 UPDATE welcomes SET image_subtitle = $1 WHERE guild_id = $2
 `
@@ -133,6 +1088,20 @@ func (q *Queries) SetWelcomeImageTitle(ctx context.Context, arg SetWelcomeImageT
 	return err
 }
 
+const setWelcomeLayoutName = `-- name: SetWelcomeLayoutName :exec
+UPDATE welcomes SET layout_name = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeLayoutNameParams struct {
+	LayoutName string
+	GuildID    string
+}
+
+func (q *Queries) SetWelcomeLayoutName(ctx context.Context, arg SetWelcomeLayoutNameParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeLayoutName, arg.LayoutName, arg.GuildID)
+	return err
+}
+
 const setWelcomeMessageText = `-- name: SetWelcomeMessageText :exec
 UPDATE welcomes SET message_text = $1 WHERE guild_id = $2
 `
@@ -161,6 +1130,142 @@ func (q *Queries) SetWelcomeMessageType(ctx context.Context, arg SetWelcomeMessa
 	return err
 }
 
+const setWelcomeOverlayColor = `-- name: SetWelcomeOverlayColor :exec
+UPDATE welcomes SET overlay_color = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeOverlayColorParams struct {
+	OverlayColor string
+	GuildID      string
+}
+
+func (q *Queries) SetWelcomeOverlayColor(ctx context.Context, arg SetWelcomeOverlayColorParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeOverlayColor, arg.OverlayColor, arg.GuildID)
+	return err
+}
+
+const setWelcomeOverlayOpacity = `-- name: SetWelcomeOverlayOpacity :exec
+UPDATE welcomes SET overlay_opacity = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeOverlayOpacityParams struct {
+	OverlayOpacity int32
+	GuildID        string
+}
+
+func (q *Queries) SetWelcomeOverlayOpacity(ctx context.Context, arg SetWelcomeOverlayOpacityParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeOverlayOpacity, arg.OverlayOpacity, arg.GuildID)
+	return err
+}
+
+const setWelcomeRingColor = `-- name: SetWelcomeRingColor :exec
+UPDATE welcomes SET ring_color = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeRingColorParams struct {
+	RingColor string
+	GuildID   string
+}
+
+func (q *Queries) SetWelcomeRingColor(ctx context.Context, arg SetWelcomeRingColorParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeRingColor, arg.RingColor, arg.GuildID)
+	return err
+}
+
+const setWelcomeRingWidth = `-- name: SetWelcomeRingWidth :exec
+UPDATE welcomes SET ring_width = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeRingWidthParams struct {
+	RingWidth int32
+	GuildID   string
+}
+
+func (q *Queries) SetWelcomeRingWidth(ctx context.Context, arg SetWelcomeRingWidthParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeRingWidth, arg.RingWidth, arg.GuildID)
+	return err
+}
+
+const setWelcomeTextColor = `-- name: SetWelcomeTextColor :exec
+UPDATE welcomes SET text_color = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeTextColorParams struct {
+	TextColor string
+	GuildID   string
+}
+
+func (q *Queries) SetWelcomeTextColor(ctx context.Context, arg SetWelcomeTextColorParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeTextColor, arg.TextColor, arg.GuildID)
+	return err
+}
+
+const setWelcomeTimezone = `-- name: SetWelcomeTimezone :exec
+UPDATE welcomes SET timezone = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeTimezoneParams struct {
+	Timezone string
+	GuildID  string
+}
+
+func (q *Queries) SetWelcomeTimezone(ctx context.Context, arg SetWelcomeTimezoneParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeTimezone, arg.Timezone, arg.GuildID)
+	return err
+}
+
+const setWelcomeVariantMode = `-- name: SetWelcomeVariantMode :exec
+UPDATE welcomes SET variant_mode = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeVariantModeParams struct {
+	VariantMode string
+	GuildID     string
+}
+
+func (q *Queries) SetWelcomeVariantMode(ctx context.Context, arg SetWelcomeVariantModeParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeVariantMode, arg.VariantMode, arg.GuildID)
+	return err
+}
+
+const setWelcomeWaveButton = `-- name: SetWelcomeWaveButton :exec
+UPDATE welcomes SET wave_button = $1 WHERE guild_id = $2
+`
+
+type SetWelcomeWaveButtonParams struct {
+	WaveButton bool
+	GuildID    string
+}
+
+func (q *Queries) SetWelcomeWaveButton(ctx context.Context, arg SetWelcomeWaveButtonParams) error {
+	_, err := q.db.ExecContext(ctx, setWelcomeWaveButton, arg.WaveButton, arg.GuildID)
+	return err
+}
+
+const upsertJoinRole = `-- name: UpsertJoinRole :exec
+INSERT INTO join_roles (guild_id, role_id, delay_seconds, skip_bots)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (guild_id, role_id) DO UPDATE
+	SET delay_seconds = $3, skip_bots = $4
+`
+
+type UpsertJoinRoleParams struct {
+	GuildID      string
+	RoleID       string
+	DelaySeconds int32
+	SkipBots     bool
+}
+
+func (q *Queries) UpsertJoinRole(ctx context.Context, arg UpsertJoinRoleParams) error {
+	_, err := q.db.ExecContext(ctx, upsertJoinRole,
+		arg.GuildID,
+		arg.RoleID,
+		arg.DelaySeconds,
+		arg.SkipBots,
+	)
+	return err
+}
+
 const upsertKV = `-- name: UpsertKV :exec
 INSERT INTO kv_pairs (k, v)
 	VALUES ($1, $2)
@@ -177,3 +1282,59 @@ func (q *Queries) UpsertKV(ctx context.Context, arg UpsertKVParams) error {
 	_, err := q.db.ExecContext(ctx, upsertKV, arg.K, arg.V)
 	return err
 }
+
+const upsertWelcomeButton = `-- name: UpsertWelcomeButton :exec
+INSERT INTO welcome_buttons (guild_id, label, url)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (guild_id, label) DO UPDATE
+	SET url = $3
+`
+
+type UpsertWelcomeButtonParams struct {
+	GuildID string
+	Label   string
+	Url     string
+}
+
+func (q *Queries) UpsertWelcomeButton(ctx context.Context, arg UpsertWelcomeButtonParams) error {
+	_, err := q.db.ExecContext(ctx, upsertWelcomeButton,
+		arg.GuildID,
+		arg.Label,
+		arg.Url,
+	)
+	return err
+}
+
+const upsertWelcomeFont = `-- name: UpsertWelcomeFont :exec
+INSERT INTO welcome_fonts (guild_id, font)
+	VALUES ($1, $2)
+	ON CONFLICT (guild_id) DO UPDATE
+	SET font = $2
+`
+
+type UpsertWelcomeFontParams struct {
+	GuildID string
+	Font    []byte
+}
+
+func (q *Queries) UpsertWelcomeFont(ctx context.Context, arg UpsertWelcomeFontParams) error {
+	_, err := q.db.ExecContext(ctx, upsertWelcomeFont, arg.GuildID, arg.Font)
+	return err
+}
+
+const upsertWelcomeImage = `-- name: UpsertWelcomeImage :exec
+INSERT INTO welcome_images (guild_id, image)
+	VALUES ($1, $2)
+	ON CONFLICT (guild_id) DO UPDATE
+	SET image = $2
+`
+
+type UpsertWelcomeImageParams struct {
+	GuildID string
+	Image   []byte
+}
+
+func (q *Queries) UpsertWelcomeImage(ctx context.Context, arg UpsertWelcomeImageParams) error {
+	_, err := q.db.ExecContext(ctx, upsertWelcomeImage, arg.GuildID, arg.Image)
+	return err
+}
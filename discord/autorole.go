@@ -0,0 +1,239 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/events"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/json"
+	"github.com/disgoorg/snowflake/v2"
+
+	"github.com/ftqo/kirby/database/queries"
+)
+
+var minRoleDelay, maxRoleDelay = 0, 3600
+
+// checkAssignable makes sure kirby is allowed to give out a role
+func checkAssignable(client bot.Client, guildID snowflake.ID, role discord.Role, perms *discord.Permissions) error {
+	if role.ID == guildID {
+		return errors.New("everyone already has the @everyone role")
+	}
+	if role.Managed {
+		return fmt.Errorf("%s is managed by an integration and can't be given out", role.Mention())
+	}
+	if perms != nil && !perms.Has(discord.PermissionManageRoles) && !perms.Has(discord.PermissionAdministrator) {
+		return errors.New("kirby needs the manage roles permission")
+	}
+
+	self, err := client.Rest().GetMember(guildID, client.ID())
+	if err != nil {
+		return fmt.Errorf("failed to get kirby's roles: %v", err)
+	}
+	roles, err := client.Rest().GetRoles(guildID)
+	if err != nil {
+		return fmt.Errorf("failed to get roles: %v", err)
+	}
+	highest := 0
+	for _, r := range roles {
+		for _, id := range self.RoleIDs {
+			if r.ID == id && r.Position > highest {
+				highest = r.Position
+			}
+		}
+	}
+	if role.Position >= highest {
+		return fmt.Errorf("kirby's highest role must be above %s to give it out", role.Mention())
+	}
+	return nil
+}
+
+// assignJoinRoles gives a new member the guild's join roles, waiting out each role's delay
+func (k *kirby) assignJoinRoles(client bot.Client, guildID snowflake.ID, member discord.Member) {
+	log := client.Logger()
+	q := queries.New(k.db)
+	roles, err := q.GetJoinRoles(context.Background(), guildID.String())
+	if err != nil {
+		log.Errorf("failed to get join roles from database: %v", err)
+		return
+	}
+	for _, jr := range roles {
+		if jr.SkipBots && member.User.Bot {
+			continue
+		}
+		roleID, err := snowflake.Parse(jr.RoleID)
+		if err != nil {
+			log.Errorf("failed to parse join role ID: %v", err)
+			continue
+		}
+		give := func() {
+			err := client.Rest().AddMemberRole(guildID, member.User.ID, roleID, rest.WithReason("join role"))
+			if err != nil {
+				log.Errorf("failed to give join role %s to member %s in guild %s: %s", roleID, member.User.ID, guildID, describeRoleError(err))
+			}
+		}
+		if jr.DelaySeconds > 0 {
+			time.AfterFunc(time.Duration(jr.DelaySeconds)*time.Second, give)
+		} else {
+			give()
+		}
+	}
+}
+
+// describeRoleError explains the usual reasons discord refuses to give out a role
+func describeRoleError(err error) string {
+	var restErr *rest.Error
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		switch restErr.Response.StatusCode {
+		case http.StatusForbidden:
+			return fmt.Sprintf("missing permissions, kirby needs manage roles and a role above it: %v", err)
+		case http.StatusNotFound:
+			return fmt.Sprintf("the role or member no longer exists: %v", err)
+		}
+	}
+	return err.Error()
+}
+
+func (k *kirby) autoroleCommand() command {
+	return command{
+		def: discord.SlashCommandCreate{
+			Name:                     "autorole",
+			Description:              "several commands for giving roles to new members",
+			DefaultMemberPermissions: json.NewNullablePtr(discord.PermissionManageRoles),
+			Options: []discord.ApplicationCommandOption{
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "add",
+					Description: "give a role to members when they join",
+					Options: []discord.ApplicationCommandOption{
+						discord.ApplicationCommandOptionRole{
+							Name:        "role",
+							Description: "the role to give",
+							Required:    true,
+						},
+						discord.ApplicationCommandOptionInt{
+							Name:        "delay",
+							Description: "how many seconds to wait after a member joins",
+							Required:    false,
+							MinValue:    &minRoleDelay,
+							MaxValue:    &maxRoleDelay,
+						},
+						discord.ApplicationCommandOptionBool{
+							Name:        "skip_bots",
+							Description: "whether to leave bots without the role",
+							Required:    false,
+						},
+					},
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "remove",
+					Description: "stop giving a role to members when they join",
+					Options: []discord.ApplicationCommandOption{
+						discord.ApplicationCommandOptionRole{
+							Name:        "role",
+							Description: "the role to stop giving",
+							Required:    true,
+						},
+					},
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "list",
+					Description: "list the roles given to members when they join",
+				},
+			},
+		},
+		handler: func(e *events.ApplicationCommandInteractionCreate) {
+			log := e.Client().Logger()
+			data := e.SlashCommandInteractionData()
+			q := queries.New(k.db)
+			guildID := *e.GuildID()
+
+			// set once the response is deferred, after which replies are follow ups
+			deferred := false
+			reply := func(content string) {
+				msg := discord.NewMessageCreateBuilder().SetContent(content).SetEphemeral(true).Build()
+				var err error
+				if deferred {
+					_, err = e.Client().Rest().CreateFollowupMessage(e.ApplicationID(), e.Token(), msg)
+				} else {
+					err = e.CreateMessage(msg)
+				}
+				if err != nil {
+					log.Errorf("failed to send message responding to autorole %s: %v", *data.SubCommandName, err)
+				}
+			}
+
+			switch *data.SubCommandName {
+			case "add":
+				role := data.Role("role")
+				// checking the role takes a couple of requests, which could miss the deadline for responding
+				err := e.DeferCreateMessage(true)
+				if err != nil {
+					log.Errorf("failed to defer message responding to autorole add: %v", err)
+					return
+				}
+				deferred = true
+				err = checkAssignable(e.Client(), guildID, role, e.AppPermissions())
+				if err != nil {
+					reply("can't give out that role: " + err.Error())
+					return
+				}
+				delay, _ := data.OptInt("delay")
+				skipBots, _ := data.OptBool("skip_bots")
+				err = q.UpsertJoinRole(context.Background(), queries.UpsertJoinRoleParams{
+					GuildID:      guildID.String(),
+					RoleID:       role.ID.String(),
+					DelaySeconds: int32(delay),
+					SkipBots:     skipBots,
+				})
+				if err != nil {
+					log.Errorf("failed to upsert join role: %v", err)
+					reply("failed to save join role!")
+					return
+				}
+				reply(fmt.Sprintf("new members will get %s!", role.Mention()))
+			case "remove":
+				role := data.Role("role")
+				n, err := q.DeleteJoinRole(context.Background(), queries.DeleteJoinRoleParams{GuildID: guildID.String(), RoleID: role.ID.String()})
+				if err != nil {
+					log.Errorf("failed to delete join role: %v", err)
+					reply("failed to remove join role!")
+					return
+				}
+				if n == 0 {
+					reply(fmt.Sprintf("%s isn't a join role!", role.Mention()))
+					return
+				}
+				reply(fmt.Sprintf("new members won't get %s anymore!", role.Mention()))
+			case "list":
+				roles, err := q.GetJoinRoles(context.Background(), guildID.String())
+				if err != nil {
+					log.Errorf("failed to get join roles: %v", err)
+					reply("failed to get join roles!")
+					return
+				}
+				if len(roles) == 0 {
+					reply("no join roles set, use `/autorole add` to add one!")
+					return
+				}
+				lines := make([]string, len(roles))
+				for i, jr := range roles {
+					line := "<@&" + jr.RoleID + ">"
+					if jr.DelaySeconds > 0 {
+						line += fmt.Sprintf(" after %ds", jr.DelaySeconds)
+					}
+					if jr.SkipBots {
+						line += ", not for bots"
+					}
+					lines[i] = line
+				}
+				reply("join roles:\n" + strings.Join(lines, "\n"))
+			}
+		},
+	}
+}
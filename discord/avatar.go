@@ -0,0 +1,150 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ftqo/kirby/card"
+	"github.com/ftqo/kirby/lru"
+)
+
+const (
+	avatarTimeout  = 5 * time.Second
+	avatarRetries  = 2
+	avatarBackoff  = 500 * time.Millisecond
+	maxAvatarBytes = 8 << 20
+
+	// size the default avatar is drawn at, layers scale it down to fit
+	defaultAvatarSize = 512
+)
+
+var errAvatarTooLarge = errors.New("avatar is too large")
+
+// avatarFetcher downloads avatars, retrying failed requests a few times and caching still ones
+type avatarFetcher struct {
+	client *http.Client
+	cache  *lru.Cache[string, image.Image]
+	// deadline for each attempt
+	timeout  time.Duration
+	retries  int
+	backoff  time.Duration
+	maxBytes int64
+}
+
+func newAvatarFetcher(client *http.Client, cacheBytes int64) *avatarFetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &avatarFetcher{
+		client:   client,
+		cache:    lru.New[string, image.Image](cacheBytes),
+		timeout:  avatarTimeout,
+		retries:  avatarRetries,
+		backoff:  avatarBackoff,
+		maxBytes: maxAvatarBytes,
+	}
+}
+
+// image fetches and decodes a still avatar, using the cache if the avatar has a hash
+func (f *avatarFetcher) image(ctx context.Context, hash, url string) (image.Image, error) {
+	if hash != "" {
+		if img, ok := f.cache.Get(hash); ok {
+			return img, nil
+		}
+	}
+	raw, err := f.fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode avatar: %v", err)
+	}
+	if hash != "" {
+		f.cache.Add(hash, img, imageCost(img))
+	}
+	return img, nil
+}
+
+// imageOrDefault is image, but gives the default avatar along with the error if the avatar can't be fetched
+func (f *avatarFetcher) imageOrDefault(ctx context.Context, hash, url string) (image.Image, error) {
+	img, err := f.image(ctx, hash, url)
+	if err != nil {
+		return card.DefaultAvatar(defaultAvatarSize), err
+	}
+	return img, nil
+}
+
+// animated fetches and decodes every frame of an animated avatar
+func (f *avatarFetcher) animated(ctx context.Context, url string) (*gif.GIF, error) {
+	raw, err := f.fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode animated avatar: %v", err)
+	}
+	return g, nil
+}
+
+// fetch downloads url, retrying on network errors and server side failures
+func (f *avatarFetcher) fetch(ctx context.Context, url string) ([]byte, error) {
+	var err error
+	for attempt := 0; attempt <= f.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("failed to get avatar: %v (last error: %v)", ctx.Err(), err)
+			case <-time.After(f.backoff * time.Duration(attempt)):
+			}
+		}
+		var raw []byte
+		var retry bool
+		raw, retry, err = f.try(ctx, url)
+		if err == nil {
+			return raw, nil
+		}
+		if !retry {
+			break
+		}
+	}
+	return nil, fmt.Errorf("failed to get avatar: %w", err)
+}
+
+// try makes a single attempt at downloading url, reporting whether it is worth trying again
+func (f *avatarFetcher) try(ctx context.Context, url string) ([]byte, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("status %s", resp.Status)
+	}
+	if resp.ContentLength > f.maxBytes {
+		return nil, false, errAvatarTooLarge
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read body: %v", err)
+	}
+	if int64(len(raw)) > f.maxBytes {
+		return nil, false, errAvatarTooLarge
+	}
+	return raw, false, nil
+}
@@ -0,0 +1,195 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testAvatar is a small png for the test servers to send
+func testAvatar(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	img.Set(0, 0, color.RGBA{R: 0xff, A: 0xff})
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatalf("failed to encode test avatar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// testFetcher returns a fetcher with short timings so failures don't slow the tests down
+func testFetcher(srv *httptest.Server) *avatarFetcher {
+	f := newAvatarFetcher(srv.Client(), 1<<20)
+	f.timeout = 100 * time.Millisecond
+	f.backoff = time.Millisecond
+	return f
+}
+
+// countingServer serves each request with handle, counting how many requests it gets
+func countingServer(t *testing.T, handle func(w http.ResponseWriter, r *http.Request, n int32)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handle(w, r, requests.Add(1))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestAvatarFetchTimeout(t *testing.T) {
+	srv, requests := countingServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		<-r.Context().Done()
+	})
+	f := testFetcher(srv)
+
+	start := time.Now()
+	_, err := f.image(context.Background(), "", srv.URL)
+	if err == nil {
+		t.Fatal("got an avatar from a server that never answers")
+	}
+	if got, want := requests.Load(), int32(f.retries+1); got != want {
+		t.Errorf("made %d requests, want %d", got, want)
+	}
+	if elapsed := time.Since(start); elapsed > 5*f.timeout*time.Duration(f.retries+1) {
+		t.Errorf("took %v to give up, the timeout is %v per attempt", elapsed, f.timeout)
+	}
+}
+
+func TestAvatarFetchRetriesServerErrors(t *testing.T) {
+	avatar := testAvatar(t)
+	srv, requests := countingServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(avatar)
+	})
+	f := testFetcher(srv)
+
+	img, err := f.image(context.Background(), "", srv.URL)
+	if err != nil {
+		t.Fatalf("failed to get avatar after a 503: %v", err)
+	}
+	if img.Bounds().Dx() != 16 {
+		t.Errorf("avatar is %v, want 16x16", img.Bounds())
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("made %d requests, want 2", got)
+	}
+}
+
+func TestAvatarFetchDoesNotRetryNotFound(t *testing.T) {
+	srv, requests := countingServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		http.NotFound(w, r)
+	})
+	f := testFetcher(srv)
+
+	_, err := f.image(context.Background(), "", srv.URL)
+	if err == nil {
+		t.Fatal("got an avatar from a 404")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+}
+
+func TestAvatarFetchSizeLimit(t *testing.T) {
+	avatar := testAvatar(t)
+	for _, c := range []struct {
+		name string
+		// whether the server says how large the avatar is up front
+		length bool
+	}{
+		{"content length", true},
+		{"chunked", false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			srv, requests := countingServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+				if !c.length {
+					// flushing before writing the body sends it chunked, without a length
+					w.(http.Flusher).Flush()
+				}
+				w.Write(avatar)
+			})
+			f := testFetcher(srv)
+			f.maxBytes = int64(len(avatar)) - 1
+
+			_, err := f.image(context.Background(), "", srv.URL)
+			if !errors.Is(err, errAvatarTooLarge) {
+				t.Errorf("got error %v, want %v", err, errAvatarTooLarge)
+			}
+			if got := requests.Load(); got != 1 {
+				t.Errorf("made %d requests, want 1", got)
+			}
+
+			f.maxBytes = int64(len(avatar))
+			_, err = f.image(context.Background(), "", srv.URL)
+			if err != nil {
+				t.Errorf("failed to get avatar of exactly the limit: %v", err)
+			}
+		})
+	}
+}
+
+func TestAvatarFetchFallsBackToDefault(t *testing.T) {
+	srv, _ := countingServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		http.NotFound(w, r)
+	})
+	f := testFetcher(srv)
+
+	img, err := f.imageOrDefault(context.Background(), "", srv.URL)
+	if err == nil {
+		t.Error("got no error for a missing avatar")
+	}
+	if img == nil {
+		t.Fatal("got no avatar, want the default")
+	}
+	if got := img.Bounds(); got.Dx() != defaultAvatarSize || got.Dy() != defaultAvatarSize {
+		t.Errorf("default avatar is %v, want %dx%d", got, defaultAvatarSize, defaultAvatarSize)
+	}
+}
+
+func TestAvatarFetchCachesByHash(t *testing.T) {
+	avatar := testAvatar(t)
+	srv, requests := countingServer(t, func(w http.ResponseWriter, r *http.Request, n int32) {
+		w.Write(avatar)
+	})
+	f := testFetcher(srv)
+
+	for i := 0; i < 2; i++ {
+		_, err := f.image(context.Background(), "hash", srv.URL)
+		if err != nil {
+			t.Fatalf("failed to get avatar: %v", err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("made %d requests for the same hash, want 1", got)
+	}
+
+	// default avatars have no hash and aren't cached, so they shouldn't count as misses either
+	for i := 0; i < 2; i++ {
+		_, err := f.image(context.Background(), "", srv.URL)
+		if err != nil {
+			t.Fatalf("failed to get avatar: %v", err)
+		}
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("made %d requests, want 3", got)
+	}
+	if s := f.cache.Stats(); s.Hits != 1 || s.Misses != 1 {
+		t.Errorf("cache has %d hits and %d misses, want 1 and 1", s.Hits, s.Misses)
+	}
+}
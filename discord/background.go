@@ -0,0 +1,141 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/log"
+
+	"github.com/ftqo/kirby/assets"
+	"github.com/ftqo/kirby/card"
+	"github.com/ftqo/kirby/database/queries"
+)
+
+const (
+	customImageName = "custom"
+
+	// size of the embedded backgrounds, uploads are cropped to match
+	backgroundWidth  = 848
+	backgroundHeight = 477
+
+	maxBackgroundBytes  = 8 << 20
+	minBackgroundWidth  = backgroundWidth / 2
+	minBackgroundHeight = backgroundHeight / 2
+	maxBackgroundWidth  = 4096
+	maxBackgroundHeight = 4096
+)
+
+// fetchBackground downloads and validates an uploaded background, returning it cover cropped to the card size
+func fetchBackground(ctx context.Context, att discord.Attachment) (image.Image, error) {
+	if att.Size > maxBackgroundBytes {
+		return nil, fmt.Errorf("image is larger than %d MiB", maxBackgroundBytes>>20)
+	}
+	if att.Width != nil && att.Height != nil && !validBackgroundBounds(*att.Width, *att.Height) {
+		return nil, fmt.Errorf("image must be between %dx%d and %dx%d pixels", minBackgroundWidth, minBackgroundHeight, maxBackgroundWidth, maxBackgroundHeight)
+	}
+
+	raw, err := downloadAttachment(ctx, att, maxBackgroundBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the dimensions before decoding the whole image
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("image must be a png, jpeg, or gif")
+	}
+	if !validBackgroundBounds(cfg.Width, cfg.Height) {
+		return nil, fmt.Errorf("image must be between %dx%d and %dx%d pixels", minBackgroundWidth, minBackgroundHeight, maxBackgroundWidth, maxBackgroundHeight)
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s image: %v", format, err)
+	}
+	return card.CoverCrop(img, backgroundWidth, backgroundHeight), nil
+}
+
+// downloadAttachment downloads an attachment, failing if it is larger than maxBytes
+func downloadAttachment(ctx context.Context, att discord.Attachment, maxBytes int) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", att.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for attachment: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download attachment: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download attachment: status %s", resp.Status)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment: %v", err)
+	}
+	if len(raw) > maxBytes {
+		return nil, fmt.Errorf("file is larger than %d MiB", maxBytes>>20)
+	}
+	return raw, nil
+}
+
+func validBackgroundBounds(w, h int) bool {
+	return w >= minBackgroundWidth && h >= minBackgroundHeight && w <= maxBackgroundWidth && h <= maxBackgroundHeight
+}
+
+// uploadedBackground fetches an uploaded background and encodes it for storage
+func uploadedBackground(ctx context.Context, att discord.Attachment) ([]byte, error) {
+	img, err := fetchBackground(ctx, att)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := encodeBackground(img)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %v", err)
+	}
+	return raw, nil
+}
+
+// encodeBackground flattens and encodes a background for storage
+func encodeBackground(img image.Image) ([]byte, error) {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+	buf := bytes.Buffer{}
+	err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: 95})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// welcomeBackground returns the background for a welcome, falling back to the original image
+func welcomeBackground(ctx context.Context, log log.Logger, q *queries.Queries, a *assets.Assets, guildID string, imageName string) image.Image {
+	if imageName == customImageName {
+		raw, err := q.GetWelcomeImage(ctx, guildID)
+		if err != nil {
+			log.Errorf("failed to get custom welcome image from database: %v", err)
+			return a.Images["original"]
+		}
+		img, _, err := image.Decode(bytes.NewReader(raw))
+		if err != nil {
+			log.Errorf("failed to decode custom welcome image: %v", err)
+			return a.Images["original"]
+		}
+		return img
+	}
+	if img, ok := a.Images[imageName]; ok {
+		return img
+	}
+	log.Warnf("unknown welcome image %s, using original", imageName)
+	return a.Images["original"]
+}
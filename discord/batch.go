@@ -0,0 +1,206 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/log"
+	"github.com/disgoorg/snowflake/v2"
+
+	"github.com/ftqo/kirby/card"
+)
+
+const (
+	collageLayout = "collage"
+	// more avatars than this don't fit on a card
+	maxCollageAvatars = 24
+	// discord's limit on message content
+	maxContentLength = 2000
+)
+
+// joinBatcher holds back a guild's joins for its window, so that when more than its threshold
+// join within it they can be welcomed together once the window ends
+type joinBatcher struct {
+	mu      sync.Mutex
+	batches map[snowflake.ID]*joinBatch
+	flush   func(held []*renderJob, collage bool)
+}
+
+type joinBatch struct {
+	threshold int
+	// set for windows following one that was sent as a collage, since the raid may still be going
+	raid bool
+	held []*renderJob
+}
+
+func newJoinBatcher(flush func(held []*renderJob, collage bool)) *joinBatcher {
+	return &joinBatcher{
+		batches: make(map[snowflake.ID]*joinBatch),
+		flush:   flush,
+	}
+}
+
+// hold holds a join back until the end of its guild's window
+func (b *joinBatcher) hold(j *renderJob, threshold int, window time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	jb, ok := b.batches[j.guildID]
+	if !ok {
+		jb = &joinBatch{}
+		b.batches[j.guildID] = jb
+		b.expire(j.guildID, jb, window)
+	}
+	jb.threshold = threshold
+	jb.held = append(jb.held, j)
+}
+
+// expire flushes a batch once its window is over, as a collage if more than the threshold joined,
+// starting the next window right away if it was
+func (b *joinBatcher) expire(guildID snowflake.ID, jb *joinBatch, window time.Duration) {
+	time.AfterFunc(window, func() {
+		b.mu.Lock()
+		delete(b.batches, guildID)
+		held := jb.held
+		collage := len(held) > jb.threshold || (jb.raid && len(held) > 1)
+		if collage {
+			next := &joinBatch{threshold: jb.threshold, raid: true}
+			b.batches[guildID] = next
+			b.expire(guildID, next, window)
+		}
+		b.mu.Unlock()
+
+		if len(held) > 0 {
+			b.flush(held, collage)
+		}
+	})
+}
+
+// sendBatch sends held joins as a single collage, or each on its own if there weren't enough for one
+func (k *kirby) sendBatch(held []*renderJob, collage bool) {
+	if !collage {
+		for _, j := range held {
+			k.send(j)
+		}
+		return
+	}
+	// the newest join has the most up to date settings and member count
+	j := *held[len(held)-1]
+	j.batch = make([]welcomeReplace, len(held))
+	for i, h := range held {
+		j.batch[i] = h.wr
+	}
+	k.send(&j)
+}
+
+// mentionList joins the mentions of members, leaving off whatever doesn't fit in max characters
+func mentionList(members []welcomeReplace, max int) string {
+	var sb strings.Builder
+	for i, m := range members {
+		// leave room to say how many members are left after this one, unless it is the last
+		room := 0
+		if i < len(members)-1 {
+			room = len(fmt.Sprintf(" and %d more", len(members)-i-1))
+		}
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		if sb.Len()+len(m.mention)+room > max {
+			sb.WriteString(fmt.Sprintf("and %d more", len(members)-i))
+			break
+		}
+		sb.WriteString(m.mention)
+	}
+	return sb.String()
+}
+
+// generateCollageMessage welcomes several members at once with a single message
+func (k *kirby) generateCollageMessage(log log.Logger, w welcome, members []welcomeReplace) discord.MessageCreate {
+	log.Trace("generating collage message")
+	var msg discord.MessageCreate
+	newest := members[len(members)-1]
+	title := fmt.Sprintf("%d new members", len(members))
+	subtitle := fmt.Sprintf("welcome to %s!", newest.guildName)
+
+	intro := fmt.Sprintf("welcome to %s, ", newest.guildName)
+	msg.Content = intro + mentionList(members, maxContentLength-len(intro)-1) + "!"
+
+	switch w.MessageType {
+	case "embed":
+		eb := discord.NewEmbedBuilder().
+			SetTitle(title + " joined the server").
+			SetDescription(fmt.Sprintf("you are members #%d to #%d", newest.members-len(members)+1, newest.members)).
+			SetColor(int(w.EmbedColor))
+		if w.EmbedTimestamp {
+			eb.SetTimestamp(time.Now())
+		}
+		msg.Embeds = append(msg.Embeds, eb.Build())
+	case "image":
+		l, ok := k.assets.Layouts[collageLayout]
+		if !ok {
+			log.Errorf("collage layout is missing")
+			return msg
+		}
+		base, err := k.cardBase(log, w, l)
+		if err != nil {
+			log.Errorf("failed to render collage card background: %v", err)
+			return msg
+		}
+		img, err := card.Render(k.assets, l, card.Data{
+			Base:    base.image,
+			Theme:   base.theme,
+			Font:    k.welcomeFont(log, w),
+			Faces:   k.faces,
+			Avatars: k.collageAvatars(log, members),
+			Texts: map[string]string{
+				"title":    title,
+				"subtitle": subtitle,
+				"guild":    newest.guildName,
+				"members":  fmt.Sprint(newest.members),
+			},
+		})
+		if err != nil {
+			log.Errorf("failed to render collage card: %v", err)
+			return msg
+		}
+
+		enc := k.encoding(w)
+		raw, err := card.Encode(img, enc)
+		if err != nil {
+			log.Errorf("failed to encode collage card: %v", err)
+			return msg
+		}
+		msg.Files = append(msg.Files, &discord.File{
+			Name:   "welcome_collage" + card.Extension(enc.Format),
+			Reader: bytes.NewReader(raw),
+		})
+	}
+	return msg
+}
+
+// collageAvatars fetches the avatars of up to maxCollageAvatars members at once
+func (k *kirby) collageAvatars(log log.Logger, members []welcomeReplace) []image.Image {
+	if len(members) > maxCollageAvatars {
+		members = members[:maxCollageAvatars]
+	}
+	avatars := make([]image.Image, len(members))
+	wg := sync.WaitGroup{}
+	for i, m := range members {
+		wg.Add(1)
+		go func(i int, m welcomeReplace) {
+			defer wg.Done()
+			pfp, err := k.avatars.imageOrDefault(context.Background(), m.avatarHash, m.avatarURL)
+			if err != nil {
+				log.Debugf("failed to get avatar for collage, using default: %v", err)
+			}
+			avatars[i] = pfp
+		}(i, m)
+	}
+	wg.Wait()
+	return avatars
+}
@@ -0,0 +1,310 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/events"
+	"github.com/disgoorg/log"
+	"github.com/disgoorg/snowflake/v2"
+
+	"github.com/ftqo/kirby/database/queries"
+)
+
+const (
+	// followed by the id of the member being waved at, if there is just one
+	waveCustomIDPrefix = "wave:"
+	// an action row fits five buttons, one of which is for waving
+	maxLinkButtons = 4
+
+	// waves are only counted this long after a welcome, older ones are pruned every wavePruneInterval
+	waveWindow        = 7 * 24 * time.Hour
+	wavePruneInterval = time.Hour
+)
+
+var (
+	maxButtonLabel = 80
+	// longest url discord allows on a link button
+	maxButtonURL = 512
+)
+
+// welcomeComponents returns the buttons under a guild's welcome messages
+func (k *kirby) welcomeComponents(log log.Logger, w welcome, newcomer snowflake.ID) []discord.ContainerComponent {
+	q := queries.New(k.db)
+	buttons, err := q.GetWelcomeButtons(context.Background(), w.GuildID)
+	if err != nil {
+		log.Errorf("failed to get welcome buttons from database: %v", err)
+	}
+	var row []discord.InteractiveComponent
+	if w.WaveButton {
+		row = append(row, waveButton(newcomer, 0))
+	}
+	for _, b := range buttons {
+		// added before urls were limited, discord would refuse the whole message
+		if len(b.Url) > maxButtonURL {
+			log.Warnf("skipping welcome button %q with a url longer than %d characters", b.Label, maxButtonURL)
+			continue
+		}
+		row = append(row, discord.NewLinkButton(b.Label, b.Url))
+	}
+	if len(row) == 0 {
+		return nil
+	}
+	return []discord.ContainerComponent{discord.NewActionRow(row...)}
+}
+
+// waveButton is the button members click to wave at newcomers, labeled with how many have
+func waveButton(newcomer snowflake.ID, waves int64) discord.ButtonComponent {
+	label := "Wave"
+	if waves > 0 {
+		label = fmt.Sprintf("Wave (%d)", waves)
+	}
+	customID := waveCustomIDPrefix
+	if newcomer != 0 {
+		customID += newcomer.String()
+	}
+	return discord.NewSecondaryButton(label, customID).WithEmoji(discord.ComponentEmoji{Name: "👋"})
+}
+
+func (k *kirby) onComponentInteractionCreate(e *events.ComponentInteractionCreate) {
+	customID := e.Data.CustomID()
+	if strings.HasPrefix(customID, waveCustomIDPrefix) {
+		k.onWave(e, strings.TrimPrefix(customID, waveCustomIDPrefix))
+	}
+}
+
+// onWave counts a member's wave at a newcomer, once per member, and updates the count on the button
+func (k *kirby) onWave(e *events.ComponentInteractionCreate, newcomer string) {
+	log := e.Client().Logger()
+	q := queries.New(k.db)
+
+	reply := func(content string) {
+		err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent(content).SetEphemeral(true).Build())
+		if err != nil {
+			log.Errorf("failed to send message responding to wave: %v", err)
+		}
+	}
+
+	if newcomer == e.User().ID.String() {
+		reply("you can't wave at yourself, but welcome!")
+		return
+	}
+	if time.Since(e.Message.ID.Time()) > waveWindow {
+		reply("this welcome is too old to wave at!")
+		return
+	}
+	messageID := e.Message.ID.String()
+	n, err := q.InsertWelcomeWave(context.Background(), queries.InsertWelcomeWaveParams{MessageID: messageID, UserID: e.User().ID.String()})
+	if err != nil {
+		log.Errorf("failed to insert welcome wave: %v", err)
+		reply("failed to wave!")
+		return
+	}
+	if n == 0 {
+		reply("you already waved!")
+		return
+	}
+	waves, err := q.CountWelcomeWaves(context.Background(), messageID)
+	if err != nil {
+		log.Errorf("failed to count welcome waves: %v", err)
+		reply("failed to wave!")
+		return
+	}
+
+	var newcomerID snowflake.ID
+	if newcomer != "" {
+		newcomerID, _ = snowflake.Parse(newcomer)
+	}
+	components := make([]discord.ContainerComponent, len(e.Message.Components))
+	for i, c := range e.Message.Components {
+		if row, ok := c.(discord.ActionRowComponent); ok {
+			c = row.UpdateComponent(e.Data.CustomID(), waveButton(newcomerID, waves))
+		}
+		components[i] = c
+	}
+	err = e.UpdateMessage(discord.NewMessageUpdateBuilder().SetContainerComponents(components...).Build())
+	if err != nil {
+		log.Errorf("failed to update wave count: %v", err)
+	}
+}
+
+// pruneWaves periodically forgets waves older than waveWindow until ctx is done
+func (k *kirby) pruneWaves(ctx context.Context, log log.Logger) {
+	ticker := time.NewTicker(wavePruneInterval)
+	defer ticker.Stop()
+	for {
+		n, err := queries.New(k.db).DeleteWelcomeWavesBefore(context.Background(), time.Now().Add(-waveWindow))
+		if err != nil {
+			log.Errorf("failed to prune welcome waves: %v", err)
+		} else if n > 0 {
+			log.Debugf("pruned %d welcome waves", n)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (k *kirby) welcomeButtonGroup() discord.ApplicationCommandOptionSubCommandGroup {
+	return discord.ApplicationCommandOptionSubCommandGroup{
+		Name:        "button",
+		Description: "commands for the buttons under welcome messages",
+		Options: []discord.ApplicationCommandOptionSubCommand{
+			{
+				Name:        "add",
+				Description: "add a button linking somewhere, like the rules",
+				Options: []discord.ApplicationCommandOption{
+					discord.ApplicationCommandOptionString{
+						Name:        "label",
+						Description: "the text on the button",
+						Required:    true,
+						MaxLength:   &maxButtonLabel,
+					},
+					discord.ApplicationCommandOptionString{
+						Name:        "url",
+						Description: "where the button links to, like a message or channel link",
+						Required:    true,
+						MaxLength:   &maxButtonURL,
+					},
+				},
+			},
+			{
+				Name:        "remove",
+				Description: "remove a link button",
+				Options: []discord.ApplicationCommandOption{
+					discord.ApplicationCommandOptionString{
+						Name:        "label",
+						Description: "the text on the button",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "list",
+				Description: "list the buttons under welcome messages",
+			},
+			{
+				Name:        "wave",
+				Description: "set whether welcome messages have a button for members to wave at newcomers",
+				Options: []discord.ApplicationCommandOption{
+					discord.ApplicationCommandOptionBool{
+						Name:        "enabled",
+						Description: "whether to show the wave button",
+						Required:    true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (k *kirby) onWelcomeButton(e *events.ApplicationCommandInteractionCreate) {
+	log := e.Client().Logger()
+	data := e.SlashCommandInteractionData()
+	q := queries.New(k.db)
+	guildID := e.GuildID().String()
+
+	reply := func(content string) {
+		err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent(content).SetEphemeral(true).Build())
+		if err != nil {
+			log.Errorf("failed to send message responding to welcome button %s: %v", *data.SubCommandName, err)
+		}
+	}
+
+	switch *data.SubCommandName {
+	case "add":
+		label := strings.TrimSpace(data.String("label"))
+		link := strings.TrimSpace(data.String("url"))
+		if label == "" {
+			reply("a button needs a label!")
+			return
+		}
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			reply("invalid url, use a full link like `https://discord.com/channels/...`!")
+			return
+		}
+		if len(link) > maxButtonURL {
+			reply(fmt.Sprintf("button urls can be at most %d characters!", maxButtonURL))
+			return
+		}
+		buttons, err := q.GetWelcomeButtons(context.Background(), guildID)
+		if err != nil {
+			log.Errorf("failed to get welcome buttons for welcome button add: %v", err)
+			reply("failed to add button!")
+			return
+		}
+		replacing := false
+		for _, b := range buttons {
+			if b.Label == label {
+				replacing = true
+			}
+		}
+		if !replacing && len(buttons) >= maxLinkButtons {
+			reply(fmt.Sprintf("welcome messages can have at most %d link buttons, remove one first!", maxLinkButtons))
+			return
+		}
+		err = q.UpsertWelcomeButton(context.Background(), queries.UpsertWelcomeButtonParams{GuildID: guildID, Label: label, Url: link})
+		if err != nil {
+			log.Errorf("failed to upsert welcome button: %v", err)
+			reply("failed to add button!")
+			return
+		}
+		reply(fmt.Sprintf("welcome messages will have a %q button!", label))
+	case "remove":
+		label := strings.TrimSpace(data.String("label"))
+		n, err := q.DeleteWelcomeButton(context.Background(), queries.DeleteWelcomeButtonParams{GuildID: guildID, Label: label})
+		if err != nil {
+			log.Errorf("failed to delete welcome button: %v", err)
+			reply("failed to remove button!")
+			return
+		}
+		if n == 0 {
+			reply(fmt.Sprintf("there is no %q button, use `/welcome button list` to see them!", label))
+			return
+		}
+		reply(fmt.Sprintf("removed the %q button!", label))
+	case "list":
+		buttons, err := q.GetWelcomeButtons(context.Background(), guildID)
+		if err != nil {
+			log.Errorf("failed to get welcome buttons: %v", err)
+			reply("failed to get buttons!")
+			return
+		}
+		lines := []string{"welcome buttons:"}
+		if w, err := q.GetWelcome(context.Background(), guildID); err == nil && w.WaveButton {
+			lines = append(lines, "👋 wave")
+		}
+		for _, b := range buttons {
+			lines = append(lines, fmt.Sprintf("%s: <%s>", b.Label, b.Url))
+		}
+		if len(lines) == 1 {
+			reply("no welcome buttons, use `/welcome button add` or `/welcome button wave` to add some!")
+			return
+		}
+		reply(strings.Join(lines, "\n"))
+	case "wave":
+		err := q.InsertWelcome(context.Background(), defaultWelcome(guildID))
+		if err != nil {
+			log.Errorf("failed to insert default welcome for welcome button wave: %v", err)
+		}
+		enabled := data.Bool("enabled")
+		err = q.SetWelcomeWaveButton(context.Background(), queries.SetWelcomeWaveButtonParams{GuildID: guildID, WaveButton: enabled})
+		if err != nil {
+			log.Errorf("failed to set wave button for welcome button wave: %v", err)
+			reply("failed to set wave button!")
+			return
+		}
+		if enabled {
+			reply("welcome messages will have a wave button!")
+		} else {
+			reply("welcome messages won't have a wave button anymore!")
+		}
+	}
+}
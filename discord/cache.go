@@ -0,0 +1,95 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strings"
+	"time"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/log"
+
+	"github.com/ftqo/kirby/assets"
+	"github.com/ftqo/kirby/card"
+	"github.com/ftqo/kirby/database/queries"
+	"github.com/ftqo/kirby/lru"
+)
+
+const (
+	defaultAvatarCacheMiB = 64
+	defaultLayerCacheMiB  = 64
+)
+
+// avatarHash returns the hash avatars are cached by, or nothing for default avatars
+func avatarHash(u discord.User) string {
+	if u.Avatar == nil {
+		return ""
+	}
+	return *u.Avatar
+}
+
+// imageCost estimates how many bytes a decoded image takes up
+func imageCost(img image.Image) int64 {
+	b := img.Bounds()
+	return int64(b.Dx()) * int64(b.Dy()) * 4
+}
+
+// cardBase is a card's static layers along with the theme they were drawn with
+type cardBase struct {
+	image image.Image
+	theme card.Theme
+}
+
+func baseKey(w welcome, layoutName string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", w.GuildID, w.ImageName, layoutName, themeKey(w))
+}
+
+// cardBase returns the static layers of a guild's card and its theme, drawing and caching them if needed
+func (k *kirby) cardBase(log log.Logger, w welcome, l *assets.Layout) (cardBase, error) {
+	key := baseKey(w, l.Name)
+	if base, ok := k.bases.Get(key); ok {
+		return base, nil
+	}
+	bg := welcomeBackground(context.Background(), log, queries.New(k.db), k.assets, w.GuildID, w.ImageName)
+	t := theme(w, bg)
+	img, err := card.RenderBase(k.assets, l, bg, t)
+	if err != nil {
+		return cardBase{}, err
+	}
+	base := cardBase{image: img, theme: t}
+	k.bases.Add(key, base, imageCost(img))
+	return base, nil
+}
+
+// forgetBases drops a guild's cached card layers, e.g. after its custom background changes
+func (k *kirby) forgetBases(guildID string) {
+	k.bases.RemoveFunc(func(key string) bool {
+		return strings.HasPrefix(key, guildID+"/")
+	})
+}
+
+// logCacheStats periodically logs how well the caches are doing until ctx is done
+func (k *kirby) logCacheStats(ctx context.Context, log log.Logger) {
+	ticker := time.NewTicker(queueStatsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			logCache(log, "avatar", k.avatars.cache.Stats())
+			logCache(log, "card layer", k.bases.Stats())
+			logCache(log, "font", k.fonts.Stats())
+			logCache(log, "font face", k.faces.Stats())
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func logCache(log log.Logger, name string, s lru.Stats) {
+	if s.Hits == 0 && s.Misses == 0 {
+		return
+	}
+	log.Infof("%s cache: %d hits, %d misses, %d evictions, %d entries, %.1f MiB",
+		name, s.Hits, s.Misses, s.Evictions, s.Len, float64(s.Cost)/(1<<20))
+}
@@ -2,14 +2,151 @@ package discord
 
 import (
 	"context"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/disgoorg/disgo/discord"
 	"github.com/disgoorg/disgo/events"
-	"github.com/disgoorg/disgo/rest/route"
+	"github.com/disgoorg/json"
 	"github.com/disgoorg/snowflake/v2"
+	"github.com/ftqo/kirby/assets"
+	"github.com/ftqo/kirby/card"
 	"github.com/ftqo/kirby/database/queries"
 )
 
+var messageTypeChoices = []discord.ApplicationCommandOptionChoiceString{
+	{
+		Name:  "image",
+		Value: "image",
+	}, {
+		Name:  "embed",
+		Value: "embed",
+	}, {
+		Name:  "plain",
+		Value: "plain",
+	},
+}
+
+var imageChoices = []discord.ApplicationCommandOptionChoiceString{
+	{
+		Name:  "original",
+		Value: "original",
+	},
+	{
+		Name:  "beach",
+		Value: "beach",
+	},
+	{
+		Name:  "sleepy",
+		Value: "sleepy",
+	},
+	{
+		Name:  "friends",
+		Value: "friends",
+	},
+	{
+		Name:  "melon",
+		Value: "melon",
+	},
+	{
+		Name:  "sky",
+		Value: "sky",
+	},
+}
+
+var formatChoices = []discord.ApplicationCommandOptionChoiceString{
+	{
+		Name:  "default",
+		Value: "default",
+	}, {
+		Name:  "png",
+		Value: card.FormatPNG,
+	}, {
+		Name:  "jpeg",
+		Value: card.FormatJPEG,
+	}, {
+		Name:  "webp (lossless)",
+		Value: card.FormatWebP,
+	},
+}
+
+var avatarShapeChoices = []discord.ApplicationCommandOptionChoiceString{
+	{
+		Name:  "default",
+		Value: "default",
+	}, {
+		Name:  "circle",
+		Value: "circle",
+	}, {
+		Name:  "square",
+		Value: "square",
+	}, {
+		Name:  "rounded square",
+		Value: "rounded",
+	}, {
+		Name:  "squircle",
+		Value: "squircle",
+	}, {
+		Name:  "hexagon",
+		Value: "hexagon",
+	},
+}
+
+const (
+	simulatePreview = "preview"
+	simulatePost    = "post"
+)
+
+var simulateChoices = []discord.ApplicationCommandOptionChoiceString{
+	{
+		Name:  "preview (only you can see it)",
+		Value: simulatePreview,
+	}, {
+		Name:  "post (in the welcome channel)",
+		Value: simulatePost,
+	},
+}
+
+var (
+	minImageQuality, maxImageQuality     = 1, 100
+	minOpacity, maxOpacity               = 0, 100
+	minRingWidth, maxRingWidth           = 0, 20
+	minBatchThreshold, maxBatchThreshold = 0, 100
+	minBatchWindow, maxBatchWindow       = 1, 300
+)
+
+// layoutChoices lists the embedded card layouts guilds can pick
+func layoutChoices(a *assets.Assets) []discord.ApplicationCommandOptionChoiceString {
+	names := make([]string, 0, len(a.Layouts))
+	for name, l := range a.Layouts {
+		if l.Internal {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	choices := make([]discord.ApplicationCommandOptionChoiceString, len(names))
+	for i, name := range names {
+		choices[i] = discord.ApplicationCommandOptionChoiceString{Name: name, Value: name}
+	}
+	return choices
+}
+
+// avatarFrameChoices lists the embedded avatar frames
+func avatarFrameChoices(a *assets.Assets) []discord.ApplicationCommandOptionChoiceString {
+	names := make([]string, 0, len(a.Frames))
+	for name := range a.Frames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	choices := []discord.ApplicationCommandOptionChoiceString{{Name: "none", Value: "none"}}
+	for _, name := range names {
+		choices = append(choices, discord.ApplicationCommandOptionChoiceString{Name: name, Value: name})
+	}
+	return choices
+}
+
 type command struct {
 	def     discord.ApplicationCommandCreate
 	handler func(*events.ApplicationCommandInteractionCreate)
@@ -20,7 +157,7 @@ func (k *kirby) getCommands() map[string]command {
 	return map[string]command{
 		"ping": {
 			def: discord.SlashCommandCreate{
-				CommandName: "ping",
+				Name:        "ping",
 				Description: "a simple command to test if the bot is online",
 			},
 			handler: func(e *events.ApplicationCommandInteractionCreate) {
@@ -32,88 +169,196 @@ func (k *kirby) getCommands() map[string]command {
 		},
 		"welcome": {
 			def: discord.SlashCommandCreate{
-				CommandName:              "welcome",
+				Name:                     "welcome",
 				Description:              "several commands for setting up welcome messages",
-				DefaultMemberPermissions: discord.PermissionManageServer,
+				DefaultMemberPermissions: json.NewNullablePtr(discord.PermissionManageGuild),
 				Options: []discord.ApplicationCommandOption{
 					discord.ApplicationCommandOptionSubCommand{
-						CommandName: "set",
-						Description: "set welcome message options. placeholders: %guild%, %mention%, %username%, and %nickname%",
+						Name:        "set",
+						Description: "set welcome message options. texts are templates, see /welcome templates",
 						Options: []discord.ApplicationCommandOption{
 							discord.ApplicationCommandOptionChannel{
-								OptionName:  "channel",
+								Name:        "channel",
 								Description: "the channel to send welcome messages in",
 								Required:    false,
 							},
 							discord.ApplicationCommandOptionString{
-								OptionName:  "message",
+								Name:        "message",
 								Description: "the contents of the message",
 								Required:    false,
 							},
 							discord.ApplicationCommandOptionString{
-								OptionName:  "image_title",
+								Name:        "image_title",
 								Description: "the message in the top row of the image",
 								Required:    false,
 							},
 							discord.ApplicationCommandOptionString{
-								OptionName:  "image_subtitle",
+								Name:        "image_subtitle",
 								Description: "the message in the bottom row of the image",
 								Required:    false,
 							},
 							discord.ApplicationCommandOptionString{
-								OptionName:  "type",
+								Name:        "type",
 								Description: "the type of message (plain, embed, or image) for the welcome message",
 								Required:    false,
-								Choices: []discord.ApplicationCommandOptionChoiceString{
-									{
-										Name:  "image",
-										Value: "image",
-									}, {
-										Name:  "plain",
-										Value: "plain",
-									},
-								},
+								Choices:     messageTypeChoices,
 							},
 							discord.ApplicationCommandOptionString{
-								OptionName:  "image",
+								Name:        "image",
 								Description: "the background image for the welcome message",
-								Choices: []discord.ApplicationCommandOptionChoiceString{
-									{
-										Name:  "original",
-										Value: "original",
-									},
-									{
-										Name:  "beach",
-										Value: "beach",
-									},
-									{
-										Name:  "sleepy",
-										Value: "sleepy",
-									},
-									{
-										Name:  "friends",
-										Value: "friends",
-									},
-									{
-										Name:  "melon",
-										Value: "melon",
-									},
-									{
-										Name:  "sky",
-										Value: "sky",
-									},
-								},
+								Choices:     imageChoices,
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "layout",
+								Description: "the layout of the welcome image",
+								Required:    false,
+								Choices:     layoutChoices(k.assets),
+							},
+							discord.ApplicationCommandOptionBool{
+								Name:        "animated",
+								Description: "send an animated image for members with animated avatars",
+								Required:    false,
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "image_format",
+								Description: "the file format of the welcome image",
+								Required:    false,
+								Choices:     formatChoices,
+							},
+							discord.ApplicationCommandOptionInt{
+								Name:        "image_quality",
+								Description: "the quality of jpeg welcome images, from 1 to 100",
+								Required:    false,
+								MinValue:    &minImageQuality,
+								MaxValue:    &maxImageQuality,
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "overlay_color",
+								Description: "the color behind the text as a hex code, auto to match the background, or default",
+								Required:    false,
+							},
+							discord.ApplicationCommandOptionInt{
+								Name:        "overlay_opacity",
+								Description: "the opacity of the color behind the text, from 0 to 100",
+								Required:    false,
+								MinValue:    &minOpacity,
+								MaxValue:    &maxOpacity,
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "text_color",
+								Description: "the color of the text as a hex code, auto to match the background, or default",
+								Required:    false,
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "ring_color",
+								Description: "the color of the ring around the avatar as a hex code, auto, or default",
+								Required:    false,
+							},
+							discord.ApplicationCommandOptionInt{
+								Name:        "ring_width",
+								Description: "the width of the ring around the avatar in pixels",
+								Required:    false,
+								MinValue:    &minRingWidth,
+								MaxValue:    &maxRingWidth,
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "avatar_shape",
+								Description: "the shape the avatar is cut to",
+								Required:    false,
+								Choices:     avatarShapeChoices,
+							},
+							discord.ApplicationCommandOptionBool{
+								Name:        "avatar_shadow",
+								Description: "whether to draw a shadow under the avatar",
+								Required:    false,
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "avatar_frame",
+								Description: "a decorative frame drawn around the avatar",
+								Required:    false,
+								Choices:     avatarFrameChoices(k.assets),
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "timezone",
+								Description: "the timezone for dates in the welcome message, like America/New_York",
+								Required:    false,
+							},
+							discord.ApplicationCommandOptionAttachment{
+								Name:        "image_upload",
+								Description: "a custom background image for the welcome message (png, jpeg, or gif)",
+								Required:    false,
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "embed_title",
+								Description: "the title of the embed",
+								Required:    false,
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "embed_description",
+								Description: "the description of the embed",
+								Required:    false,
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "embed_color",
+								Description: "the color of the embed as a hex code (e.g. #f7a8c4)",
+								Required:    false,
+							},
+							discord.ApplicationCommandOptionString{
+								Name:        "embed_footer",
+								Description: "the footer of the embed",
+								Required:    false,
+							},
+							discord.ApplicationCommandOptionBool{
+								Name:        "embed_timestamp",
+								Description: "whether to show the time of joining in the embed",
+								Required:    false,
 							},
 						},
 					},
 					discord.ApplicationCommandOptionSubCommand{
-						CommandName: "simulate",
+						Name:        "simulate",
 						Description: "simulate a welcome message",
+						Options: []discord.ApplicationCommandOption{
+							discord.ApplicationCommandOptionString{
+								Name:        "mode",
+								Description: "whether to only show you a preview, or post the welcome in the welcome channel",
+								Required:    false,
+								Choices:     simulateChoices,
+							},
+						},
 					},
 					discord.ApplicationCommandOptionSubCommand{
-						CommandName: "reset",
+						Name:        "reset",
 						Description: "reset all welcome settings to default",
 					},
+					discord.ApplicationCommandOptionSubCommand{
+						Name:        "templates",
+						Description: "show what welcome texts can use",
+					},
+					discord.ApplicationCommandOptionSubCommand{
+						Name:        "batch",
+						Description: "welcome members with one collage when more than threshold join within the window",
+						Options: []discord.ApplicationCommandOption{
+							discord.ApplicationCommandOptionInt{
+								Name:        "threshold",
+								Description: "how many members can join within the window before they get batched, 0 to never batch",
+								Required:    true,
+								MinValue:    &minBatchThreshold,
+								MaxValue:    &maxBatchThreshold,
+							},
+							discord.ApplicationCommandOptionInt{
+								Name:        "window",
+								Description: "how many seconds welcomes are held to see if more members join",
+								Required:    true,
+								MinValue:    &minBatchWindow,
+								MaxValue:    &maxBatchWindow,
+							},
+						},
+					},
+					k.welcomeFontGroup(),
+					k.welcomeDMGroup(),
+					k.welcomeVariantGroup(),
+					k.welcomeButtonGroup(),
 				},
 			},
 			handler: func(e *events.ApplicationCommandInteractionCreate) {
@@ -121,18 +366,97 @@ func (k *kirby) getCommands() map[string]command {
 				data := e.SlashCommandInteractionData()
 				q := queries.New(k.db)
 
+				if data.SubCommandGroupName != nil {
+					switch *data.SubCommandGroupName {
+					case "font":
+						k.onWelcomeFont(e)
+					case "dm":
+						k.onWelcomeDM(e)
+					case "variant":
+						k.onWelcomeVariant(e)
+					case "button":
+						k.onWelcomeButton(e)
+					}
+					return
+				}
+
 				switch *data.SubCommandName {
 				case "set":
+					var embedColor int32
+					embedColorHex, setEmbedColor := data.OptString("embed_color")
+					if setEmbedColor {
+						var err error
+						embedColor, err = parseHexColor(embedColorHex)
+						if err != nil {
+							err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("invalid embed color, use a hex code like `#f7a8c4`!").SetEphemeral(true).Build())
+							if err != nil {
+								e.Client().Logger().Errorf("failed to send message responding to invalid embed color")
+							}
+							return
+						}
+					}
+
+					themeColors := map[string]string{}
+					for _, opt := range []string{"overlay_color", "text_color", "ring_color"} {
+						raw, ok := data.OptString(opt)
+						if !ok {
+							continue
+						}
+						c, err := parseThemeColor(raw)
+						if err != nil {
+							err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("invalid " + strings.ReplaceAll(opt, "_", " ") + ", use a hex code like `#322d3282`, `auto`, or `default`!").SetEphemeral(true).Build())
+							if err != nil {
+								e.Client().Logger().Errorf("failed to send message responding to invalid %s", opt)
+							}
+							return
+						}
+						themeColors[opt] = c
+					}
+
+					if err := validateTemplates(data.OptString, "message", "image_title", "image_subtitle", "embed_title", "embed_description", "embed_footer"); err != nil {
+						err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent(err.Error()).SetEphemeral(true).Build())
+						if err != nil {
+							e.Client().Logger().Errorf("failed to send message responding to invalid welcome template")
+						}
+						return
+					}
+					tz, setTimezone := data.OptString("timezone")
+					if setTimezone {
+						if _, err := time.LoadLocation(tz); err != nil || tz == "" {
+							err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("invalid timezone, use a name like `America/New_York` or `UTC`!").SetEphemeral(true).Build())
+							if err != nil {
+								e.Client().Logger().Errorf("failed to send message responding to invalid timezone")
+							}
+							return
+						}
+					}
+
 					err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("setting welcome config!").SetEphemeral(true).Build())
 					if err != nil {
 						e.Client().Logger().Errorf("failed to set send message responding to welcome set")
 					}
 
+					// download the upload before opening the transaction, it may take a while
+					var customImage []byte
+					if upload, ok := data.OptAttachment("image_upload"); ok {
+						customImage, err = uploadedBackground(context.Background(), upload)
+						if err != nil {
+							_, err = e.Client().Rest().CreateFollowupMessage(e.ApplicationID(), e.Token(), discord.NewMessageCreateBuilder().SetContent("failed to use uploaded image: "+err.Error()).SetEphemeral(true).Build())
+							if err != nil {
+								e.Client().Logger().Errorf("failed to send followup message for invalid welcome image: %v", err)
+							}
+						}
+					}
+
 					tx, err := k.db.Begin()
 					if err != nil {
 						e.Client().Logger().Errorf("failed to begin transaction for welcome set: %v", err)
 					}
 					q = q.WithTx(tx)
+					err = q.InsertWelcome(context.Background(), defaultWelcome(e.GuildID().String()))
+					if err != nil {
+						e.Client().Logger().Errorf("failed to insert default welcome for welcome set: %v", err)
+					}
 					if channel, ok := data.OptChannel("channel"); ok {
 						err = q.SetWelcomeChannel(context.Background(), queries.SetWelcomeChannelParams{GuildID: e.GuildID().String(), ChannelID: channel.ID.String()})
 						if err != nil {
@@ -164,35 +488,172 @@ func (k *kirby) getCommands() map[string]command {
 							e.Client().Logger().Errorf("failed to set channel for welcome set: %v", err)
 						}
 					}
+					if layout, ok := data.OptString("layout"); ok {
+						err = q.SetWelcomeLayoutName(context.Background(), queries.SetWelcomeLayoutNameParams{GuildID: e.GuildID().String(), LayoutName: layout})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set layout for welcome set: %v", err)
+						}
+					}
+					if animated, ok := data.OptBool("animated"); ok {
+						err = q.SetWelcomeAnimated(context.Background(), queries.SetWelcomeAnimatedParams{GuildID: e.GuildID().String(), Animated: animated})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set animated for welcome set: %v", err)
+						}
+					}
+					if format, ok := data.OptString("image_format"); ok {
+						// an empty format falls back to the global one
+						if format == "default" {
+							format = ""
+						}
+						err = q.SetWelcomeImageFormat(context.Background(), queries.SetWelcomeImageFormatParams{GuildID: e.GuildID().String(), ImageFormat: format})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set image format for welcome set: %v", err)
+						}
+					}
+					if quality, ok := data.OptInt("image_quality"); ok {
+						err = q.SetWelcomeImageQuality(context.Background(), queries.SetWelcomeImageQualityParams{GuildID: e.GuildID().String(), ImageQuality: int32(quality)})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set image quality for welcome set: %v", err)
+						}
+					}
+					if c, ok := themeColors["overlay_color"]; ok {
+						err = q.SetWelcomeOverlayColor(context.Background(), queries.SetWelcomeOverlayColorParams{GuildID: e.GuildID().String(), OverlayColor: c})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set overlay color for welcome set: %v", err)
+						}
+					}
+					if opacity, ok := data.OptInt("overlay_opacity"); ok {
+						err = q.SetWelcomeOverlayOpacity(context.Background(), queries.SetWelcomeOverlayOpacityParams{GuildID: e.GuildID().String(), OverlayOpacity: int32(opacity)})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set overlay opacity for welcome set: %v", err)
+						}
+					}
+					if c, ok := themeColors["text_color"]; ok {
+						err = q.SetWelcomeTextColor(context.Background(), queries.SetWelcomeTextColorParams{GuildID: e.GuildID().String(), TextColor: c})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set text color for welcome set: %v", err)
+						}
+					}
+					if c, ok := themeColors["ring_color"]; ok {
+						err = q.SetWelcomeRingColor(context.Background(), queries.SetWelcomeRingColorParams{GuildID: e.GuildID().String(), RingColor: c})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set ring color for welcome set: %v", err)
+						}
+					}
+					if width, ok := data.OptInt("ring_width"); ok {
+						err = q.SetWelcomeRingWidth(context.Background(), queries.SetWelcomeRingWidthParams{GuildID: e.GuildID().String(), RingWidth: int32(width)})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set ring width for welcome set: %v", err)
+						}
+					}
+					if shape, ok := data.OptString("avatar_shape"); ok {
+						// an empty shape falls back to the layout's
+						if shape == "default" {
+							shape = ""
+						}
+						err = q.SetWelcomeAvatarShape(context.Background(), queries.SetWelcomeAvatarShapeParams{GuildID: e.GuildID().String(), AvatarShape: shape})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set avatar shape for welcome set: %v", err)
+						}
+					}
+					if shadow, ok := data.OptBool("avatar_shadow"); ok {
+						err = q.SetWelcomeAvatarShadow(context.Background(), queries.SetWelcomeAvatarShadowParams{GuildID: e.GuildID().String(), AvatarShadow: shadow})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set avatar shadow for welcome set: %v", err)
+						}
+					}
+					if frame, ok := data.OptString("avatar_frame"); ok {
+						if frame == "none" {
+							frame = ""
+						}
+						err = q.SetWelcomeAvatarFrame(context.Background(), queries.SetWelcomeAvatarFrameParams{GuildID: e.GuildID().String(), AvatarFrame: frame})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set avatar frame for welcome set: %v", err)
+						}
+					}
+					if setTimezone {
+						err = q.SetWelcomeTimezone(context.Background(), queries.SetWelcomeTimezoneParams{GuildID: e.GuildID().String(), Timezone: tz})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set timezone for welcome set: %v", err)
+						}
+					}
+					if customImage != nil {
+						err = q.UpsertWelcomeImage(context.Background(), queries.UpsertWelcomeImageParams{GuildID: e.GuildID().String(), Image: customImage})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to upsert custom image for welcome set: %v", err)
+						}
+						err = q.SetWelcomeImageName(context.Background(), queries.SetWelcomeImageNameParams{GuildID: e.GuildID().String(), ImageName: customImageName})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set image for welcome set: %v", err)
+						}
+					}
 					if typ, ok := data.OptString("type"); ok {
 						err = q.SetWelcomeMessageType(context.Background(), queries.SetWelcomeMessageTypeParams{GuildID: e.GuildID().String(), MessageType: typ})
 						if err != nil {
 							e.Client().Logger().Errorf("failed to set channel for welcome set: %v", err)
 						}
 					}
+					if title, ok := data.OptString("embed_title"); ok {
+						err = q.SetWelcomeEmbedTitle(context.Background(), queries.SetWelcomeEmbedTitleParams{GuildID: e.GuildID().String(), EmbedTitle: title})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set embed title for welcome set: %v", err)
+						}
+					}
+					if description, ok := data.OptString("embed_description"); ok {
+						err = q.SetWelcomeEmbedDescription(context.Background(), queries.SetWelcomeEmbedDescriptionParams{GuildID: e.GuildID().String(), EmbedDescription: description})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set embed description for welcome set: %v", err)
+						}
+					}
+					if setEmbedColor {
+						err = q.SetWelcomeEmbedColor(context.Background(), queries.SetWelcomeEmbedColorParams{GuildID: e.GuildID().String(), EmbedColor: embedColor})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set embed color for welcome set: %v", err)
+						}
+					}
+					if footer, ok := data.OptString("embed_footer"); ok {
+						err = q.SetWelcomeEmbedFooter(context.Background(), queries.SetWelcomeEmbedFooterParams{GuildID: e.GuildID().String(), EmbedFooter: footer})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set embed footer for welcome set: %v", err)
+						}
+					}
+					if timestamp, ok := data.OptBool("embed_timestamp"); ok {
+						err = q.SetWelcomeEmbedTimestamp(context.Background(), queries.SetWelcomeEmbedTimestampParams{GuildID: e.GuildID().String(), EmbedTimestamp: timestamp})
+						if err != nil {
+							e.Client().Logger().Errorf("failed to set embed timestamp for welcome set: %v", err)
+						}
+					}
 					err = tx.Commit()
 					if err != nil {
 						e.Client().Logger().Errorf("failed to commit transaction for welcome set: %v", err)
 					}
+					if customImage != nil {
+						k.forgetBases(e.GuildID().String())
+					}
 
 				case "simulate":
+					post := data.String("mode") == simulatePost
 					w, err := q.GetWelcome(context.Background(), e.GuildID().String())
 					if err != nil {
-						e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("welcome channel not set, use `/welcome set` and pick a channel!").SetEphemeral(true).Build())
 						q.InsertWelcome(context.Background(), defaultWelcome(e.GuildID().String()))
-						return
+						w = queries.Welcome(defaultWelcome(e.GuildID().String()))
 					}
-					if len(w.ChannelID) == 0 {
+					if post && len(w.ChannelID) == 0 {
 						e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("welcome channel not set, use `/welcome set` and pick a channel!").SetEphemeral(true).Build())
 						return
 					}
 
-					err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("simulating welcome!").SetEphemeral(true).Build())
+					if post {
+						err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("simulating welcome!").SetEphemeral(true).Build())
+					} else {
+						// rendering can take longer than discord waits for a response
+						err = e.DeferCreateMessage(true)
+					}
 					if err != nil {
 						e.Client().Logger().Errorf("failed to set send message responding to welcome simulate")
 					}
 
-					g, ok := e.Client().Caches().Guilds().Get(*e.GuildID())
+					g, ok := e.Client().Caches().Guild(*e.GuildID())
 					if !ok {
 						rg, err := e.Client().Rest().GetGuild(*e.GuildID(), true)
 						if err != nil {
@@ -202,16 +663,18 @@ func (k *kirby) getCommands() map[string]command {
 						g.MemberCount = g.ApproximateMemberCount
 					}
 
-					wr := welcomeReplace{
-						mention:   e.Member().Mention(),
-						nickname:  e.Member().User.Username,
-						username:  e.Member().User.Tag(),
-						avatarURL: e.Member().User.EffectiveAvatarURL(discord.WithSize(512), discord.WithFormat(route.PNG)),
-						members:   g.MemberCount,
-						guildName: g.Name,
-					}
+					wr := newWelcomeReplace(e.Member().Member, g)
 
-					message := generateWelcomeMessage(e.Client().Logger(), welcome(w), wr, k.assets)
+					message := k.generateWelcomeMessage(e.Client().Logger(), k.withVariant(log, welcome(w)), wr)
+					message.Components = k.welcomeComponents(log, welcome(w), wr.userID)
+					if !post {
+						message.Flags = discord.MessageFlagEphemeral
+						_, err = e.Client().Rest().CreateFollowupMessage(e.ApplicationID(), e.Token(), message)
+						if err != nil {
+							log.Errorf("failed to send welcome preview: %v", err)
+						}
+						return
+					}
 					channel, err := snowflake.Parse(w.ChannelID)
 					if err != nil {
 						log.Errorf("failed to parse channel snowflake from channel id: %v", err)
@@ -220,6 +683,28 @@ func (k *kirby) getCommands() map[string]command {
 					if err != nil {
 						log.Error("failed to send simulated welcome message: %v", err)
 					}
+				case "templates":
+					err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent(templateHelp).SetEphemeral(true).Build())
+					if err != nil {
+						log.Errorf("failed to send message responding to welcome templates: %v", err)
+					}
+				case "batch":
+					err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("setting welcome batching!").SetEphemeral(true).Build())
+					if err != nil {
+						log.Errorf("failed to send message responding to welcome batch")
+					}
+					err = q.InsertWelcome(context.Background(), defaultWelcome(e.GuildID().String()))
+					if err != nil {
+						log.Errorf("failed to insert default welcome for welcome batch: %v", err)
+					}
+					err = q.SetWelcomeBatch(context.Background(), queries.SetWelcomeBatchParams{
+						GuildID:        e.GuildID().String(),
+						BatchThreshold: int32(data.Int("threshold")),
+						BatchWindow:    int32(data.Int("window")),
+					})
+					if err != nil {
+						log.Errorf("failed to set batching for welcome batch: %v", err)
+					}
 				case "reset":
 					err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("not implemented!").SetEphemeral(true).Build())
 					if err != nil {
@@ -228,5 +713,8 @@ func (k *kirby) getCommands() map[string]command {
 				}
 			},
 		},
+		"goodbye":   k.goodbyeCommand(),
+		"autorole":  k.autoroleCommand(),
+		"milestone": k.milestoneCommand(),
 	}
 }
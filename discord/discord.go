@@ -13,25 +13,75 @@ import (
 	"github.com/disgoorg/disgo/events"
 	"github.com/disgoorg/disgo/gateway"
 	"github.com/disgoorg/log"
+	"github.com/golang/freetype/truetype"
 	"github.com/gorilla/websocket"
 
 	"github.com/ftqo/kirby/assets"
+	"github.com/ftqo/kirby/card"
 	"github.com/ftqo/kirby/config"
 	"github.com/ftqo/kirby/database/queries"
+	"github.com/ftqo/kirby/lru"
 )
 
 type kirby struct {
-	db     *sql.DB
-	assets *assets.Assets
+	db       *sql.DB
+	assets   *assets.Assets
+	render   config.RenderConfig
+	queue    *renderQueue
+	batcher  *joinBatcher
+	variants *variantPicker
+	avatars  *avatarFetcher
+	// static card layers, see cardBase
+	bases *lru.Cache[string, cardBase]
+	// uploaded fonts by guild
+	fonts *lru.Cache[string, *truetype.Font]
+	faces *card.FaceCache
 
 	commands map[string]command
 }
 
-func Run(ctx context.Context, wg *sync.WaitGroup, log log.Logger, config config.DiscordConfig, db *sql.DB, assets *assets.Assets) {
+func Run(ctx context.Context, wg *sync.WaitGroup, log log.Logger, config config.DiscordConfig, render config.RenderConfig, db *sql.DB, assets *assets.Assets) {
 	log.Info("running discord service")
 	defer wg.Done()
 
-	k := kirby{db: db, assets: assets}
+	if render.AvatarCacheMiB <= 0 {
+		render.AvatarCacheMiB = defaultAvatarCacheMiB
+	}
+	if render.LayerCacheMiB <= 0 {
+		render.LayerCacheMiB = defaultLayerCacheMiB
+	}
+	if render.FontCacheMiB <= 0 {
+		render.FontCacheMiB = defaultFontCacheMiB
+	}
+	if render.FaceCacheMiB <= 0 {
+		render.FaceCacheMiB = defaultFaceCacheMiB
+	}
+	if !validFormat(render.Format) {
+		log.Warnf("unknown image format %q, using %s", render.Format, card.FormatJPEG)
+		render.Format = card.FormatJPEG
+	}
+	if render.MaxUploadMiB <= 0 {
+		render.MaxUploadMiB = defaultMaxUploadMiB
+	}
+	k := kirby{
+		db:       db,
+		assets:   assets,
+		render:   render,
+		avatars:  newAvatarFetcher(nil, int64(render.AvatarCacheMiB)<<20),
+		bases:    lru.New[string, cardBase](int64(render.LayerCacheMiB) << 20),
+		fonts:    lru.New[string, *truetype.Font](int64(render.FontCacheMiB) << 20),
+		faces:    card.NewFaceCache(int64(render.FaceCacheMiB) << 20),
+		variants: newVariantPicker(),
+	}
+	go k.logCacheStats(ctx, log)
+	go k.pruneWaves(ctx, log)
+	k.queue = newRenderQueue(log, render, k.process)
+	k.batcher = newJoinBatcher(k.sendBatch)
+	queueDone := make(chan struct{})
+	go func() {
+		k.queue.run(ctx)
+		close(queueDone)
+	}()
 	q := queries.New(db)
 
 	// get and parse old session and sequence
@@ -67,11 +117,13 @@ func Run(ctx context.Context, wg *sync.WaitGroup, log log.Logger, config config.
 			gateway.WithSequence(sequence),
 			gateway.WithSessionID(sessionID),
 		),
-		bot.WithCacheConfigOpts(cache.WithCacheFlags(cache.FlagGuilds), cache.WithGuildCachePolicy(cache.DefaultConfig().GuildCachePolicy)),
+		bot.WithCacheConfigOpts(cache.WithCaches(cache.FlagGuilds), cache.WithGuildCachePolicy(cache.DefaultConfig().GuildCachePolicy)),
 		bot.WithEventListeners(&events.ListenerAdapter{
 			OnReady:                         k.onReady,
 			OnGuildMemberJoin:               k.onGuildMemberJoin,
+			OnGuildMemberLeave:              k.onGuildMemberLeave,
 			OnApplicationCommandInteraction: k.onApplicationCommandInteractionCreate,
+			OnComponentInteraction:          k.onComponentInteractionCreate,
 			OnResumed:                       k.onResume,
 		}),
 		bot.WithLogger(log),
@@ -113,4 +165,5 @@ func Run(ctx context.Context, wg *sync.WaitGroup, log log.Logger, config config.
 	}
 
 	client.Gateway().CloseWithCode(context.Background(), websocket.CloseServiceRestart, "Restarting")
+	<-queueDone
 }
@@ -0,0 +1,200 @@
+package discord
+
+import (
+	"context"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/events"
+	"github.com/disgoorg/log"
+	"github.com/disgoorg/snowflake/v2"
+
+	"github.com/ftqo/kirby/database/queries"
+)
+
+const defaultDMText = "hi %mention%, welcome to %guild%! make sure to read the rules :)"
+
+// dmWelcome turns a welcome into the direct message version of it
+func dmWelcome(w welcome) welcome {
+	w.MessageText = w.DmText
+	if w.DmCard {
+		w.MessageType = "image"
+	} else {
+		w.MessageType = "plain"
+	}
+	return w
+}
+
+// sendDM queues a direct message welcome, which is replaced by fallback if it can't be delivered and fallback is set.
+// undelivered is called too if it is set
+func (k *kirby) sendDM(client bot.Client, guildID, userID snowflake.ID, w welcome, wr welcomeReplace, fallback *renderJob, undelivered func()) {
+	k.send(&renderJob{
+		client:      client,
+		kind:        "welcome dm",
+		guildID:     guildID,
+		dmUser:      userID,
+		w:           dmWelcome(w),
+		wr:          wr,
+		fallback:    fallback,
+		undelivered: undelivered,
+	})
+}
+
+// deliverDM sends a direct message, welcoming the member in the channel instead if their dms are closed
+func (k *kirby) deliverDM(log log.Logger, j *renderJob, msg discord.MessageCreate) {
+	ch, err := j.client.Rest().CreateDMChannel(j.dmUser)
+	if err == nil {
+		_, err = j.client.Rest().CreateMessage(ch.ID(), msg)
+	}
+	if err == nil {
+		return
+	}
+	log.Infof("failed to send welcome dm to %s, their dms may be closed: %v", j.dmUser, err)
+	if j.undelivered != nil {
+		j.undelivered()
+	}
+	if j.fallback != nil {
+		k.welcomeInChannel(j.fallback)
+	}
+}
+
+func (k *kirby) welcomeDMGroup() discord.ApplicationCommandOptionSubCommandGroup {
+	return discord.ApplicationCommandOptionSubCommandGroup{
+		Name:        "dm",
+		Description: "commands for welcoming members with a direct message",
+		Options: []discord.ApplicationCommandOptionSubCommand{
+			{
+				Name:        "set",
+				Description: "set direct message options. texts are templates, see /welcome templates",
+				Options: []discord.ApplicationCommandOption{
+					discord.ApplicationCommandOptionBool{
+						Name:        "enabled",
+						Description: "whether to send members a direct message when they join",
+						Required:    false,
+					},
+					discord.ApplicationCommandOptionString{
+						Name:        "message",
+						Description: "the contents of the direct message",
+						Required:    false,
+					},
+					discord.ApplicationCommandOptionBool{
+						Name:        "card",
+						Description: "whether to attach the welcome image to the direct message",
+						Required:    false,
+					},
+					discord.ApplicationCommandOptionBool{
+						Name:        "fallback",
+						Description: "whether to only welcome members in the welcome channel when their dms are closed",
+						Required:    false,
+					},
+				},
+			},
+			{
+				Name:        "simulate",
+				Description: "send yourself the welcome direct message",
+			},
+		},
+	}
+}
+
+func (k *kirby) onWelcomeDM(e *events.ApplicationCommandInteractionCreate) {
+	log := e.Client().Logger()
+	data := e.SlashCommandInteractionData()
+	q := queries.New(k.db)
+	guildID := e.GuildID().String()
+
+	switch *data.SubCommandName {
+	case "set":
+		if err := validateTemplates(data.OptString, "message"); err != nil {
+			err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent(err.Error()).SetEphemeral(true).Build())
+			if err != nil {
+				log.Errorf("failed to send message responding to invalid welcome dm template")
+			}
+			return
+		}
+
+		if fallback, ok := data.OptBool("fallback"); ok && fallback {
+			w, err := q.GetWelcome(context.Background(), guildID)
+			if err != nil || w.ChannelID == "" {
+				err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("there is no welcome channel to fall back to, set one with `/welcome set` first!").SetEphemeral(true).Build())
+				if err != nil {
+					log.Errorf("failed to send message responding to welcome dm fallback without a channel")
+				}
+				return
+			}
+		}
+
+		err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("setting welcome dm config!").SetEphemeral(true).Build())
+		if err != nil {
+			log.Errorf("failed to send message responding to welcome dm set")
+		}
+
+		tx, err := k.db.Begin()
+		if err != nil {
+			log.Errorf("failed to begin transaction for welcome dm set: %v", err)
+			return
+		}
+		defer tx.Rollback()
+		q = q.WithTx(tx)
+		err = q.InsertWelcome(context.Background(), defaultWelcome(guildID))
+		if err != nil {
+			log.Errorf("failed to insert default welcome for welcome dm set: %v", err)
+		}
+		if enabled, ok := data.OptBool("enabled"); ok {
+			err = q.SetWelcomeDMEnabled(context.Background(), queries.SetWelcomeDMEnabledParams{GuildID: guildID, DmEnabled: enabled})
+			if err != nil {
+				log.Errorf("failed to set enabled for welcome dm set: %v", err)
+			}
+		}
+		if text, ok := data.OptString("message"); ok {
+			err = q.SetWelcomeDMText(context.Background(), queries.SetWelcomeDMTextParams{GuildID: guildID, DmText: text})
+			if err != nil {
+				log.Errorf("failed to set message for welcome dm set: %v", err)
+			}
+		}
+		if card, ok := data.OptBool("card"); ok {
+			err = q.SetWelcomeDMCard(context.Background(), queries.SetWelcomeDMCardParams{GuildID: guildID, DmCard: card})
+			if err != nil {
+				log.Errorf("failed to set card for welcome dm set: %v", err)
+			}
+		}
+		if fallback, ok := data.OptBool("fallback"); ok {
+			err = q.SetWelcomeDMFallback(context.Background(), queries.SetWelcomeDMFallbackParams{GuildID: guildID, DmFallback: fallback})
+			if err != nil {
+				log.Errorf("failed to set fallback for welcome dm set: %v", err)
+			}
+		}
+		err = tx.Commit()
+		if err != nil {
+			log.Errorf("failed to commit transaction for welcome dm set: %v", err)
+		}
+	case "simulate":
+		w, err := q.GetWelcome(context.Background(), guildID)
+		if err != nil {
+			w = queries.Welcome(defaultWelcome(guildID))
+		}
+		err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("simulating welcome dm!").SetEphemeral(true).Build())
+		if err != nil {
+			log.Errorf("failed to send message responding to welcome dm simulate")
+		}
+
+		g, ok := e.Client().Caches().Guild(*e.GuildID())
+		if !ok {
+			rg, err := e.Client().Rest().GetGuild(*e.GuildID(), true)
+			if err != nil {
+				log.Errorf("failed to get guild from api for dm simulation: %v", err)
+				return
+			}
+			g = rg.Guild
+			g.MemberCount = g.ApproximateMemberCount
+		}
+		wr := newWelcomeReplace(e.Member().Member, g)
+		// no fallback, the person simulating is told their dms are closed instead
+		k.sendDM(e.Client(), *e.GuildID(), e.User().ID, welcome(w), wr, nil, func() {
+			_, err := e.Client().Rest().CreateFollowupMessage(e.ApplicationID(), e.Token(), discord.NewMessageCreateBuilder().SetContent("couldn't dm you, your dms are closed!").SetEphemeral(true).Build())
+			if err != nil {
+				log.Errorf("failed to send follow up message for welcome dm simulate: %v", err)
+			}
+		})
+	}
+}
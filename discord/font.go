@@ -0,0 +1,222 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/events"
+	"github.com/disgoorg/log"
+	"github.com/golang/freetype/truetype"
+
+	"github.com/ftqo/kirby/assets"
+	"github.com/ftqo/kirby/card"
+	"github.com/ftqo/kirby/database/queries"
+)
+
+const (
+	customFontName = "custom"
+	// an empty font name uses the layout's fonts
+	defaultFontName = ""
+
+	maxFontBytes        = 8 << 20
+	defaultFontCacheMiB = 32
+	defaultFaceCacheMiB = 32
+)
+
+// fontChoices lists the embedded fonts along with the layout's own and the guild's uploaded one
+func fontChoices(a *assets.Assets) []discord.ApplicationCommandOptionChoiceString {
+	names := make([]string, 0, len(a.Fonts))
+	for name := range a.Fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	choices := []discord.ApplicationCommandOptionChoiceString{{Name: "default", Value: "default"}}
+	for _, name := range names {
+		choices = append(choices, discord.ApplicationCommandOptionChoiceString{Name: name, Value: name})
+	}
+	return append(choices, discord.ApplicationCommandOptionChoiceString{Name: customFontName, Value: customFontName})
+}
+
+// fontSample is text an uploaded font has to be able to draw, every printable ascii character and
+// the guild's card texts
+func fontSample(w welcome) string {
+	var sb strings.Builder
+	for r := ' '; r <= '~'; r++ {
+		sb.WriteRune(r)
+	}
+	for _, text := range []string{w.ImageTitle, w.ImageSubtitle} {
+		if s, err := executeTemplate("sample", text, sampleTemplateData); err == nil {
+			sb.WriteString(" " + s)
+		}
+	}
+	return sb.String()
+}
+
+// uploadedFont downloads an uploaded font, making sure it can be drawn with by drawing sample
+func uploadedFont(ctx context.Context, att discord.Attachment, sample string) ([]byte, error) {
+	if att.Size > maxFontBytes {
+		return nil, fmt.Errorf("font is larger than %d MiB", maxFontBytes>>20)
+	}
+	raw, err := downloadAttachment(ctx, att, maxFontBytes)
+	if err != nil {
+		return nil, err
+	}
+	f, err := truetype.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("font must be a ttf, otf fonts with cff outlines aren't supported")
+	}
+	// fonts that parse can still be broken enough to crash while drawing
+	err = card.CheckFont(f, sample)
+	if err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// welcomeFont returns the font a welcome's text is drawn with, or nothing to use the layout's
+func (k *kirby) welcomeFont(log log.Logger, w welcome) *truetype.Font {
+	switch w.FontName {
+	case defaultFontName:
+		return nil
+	case customFontName:
+		if f, ok := k.fonts.Get(w.GuildID); ok {
+			return f
+		}
+		raw, err := queries.New(k.db).GetWelcomeFont(context.Background(), w.GuildID)
+		if err != nil {
+			log.Errorf("failed to get custom welcome font from database: %v", err)
+			return nil
+		}
+		f, err := truetype.Parse(raw)
+		if err != nil {
+			log.Errorf("failed to parse custom welcome font: %v", err)
+			return nil
+		}
+		k.fonts.Add(w.GuildID, f, int64(len(raw)))
+		return f
+	}
+	f, ok := k.assets.Fonts[w.FontName]
+	if !ok {
+		log.Warnf("unknown welcome font %s, using the layout's", w.FontName)
+		return nil
+	}
+	return f
+}
+
+func (k *kirby) welcomeFontGroup() discord.ApplicationCommandOptionSubCommandGroup {
+	return discord.ApplicationCommandOptionSubCommandGroup{
+		Name:        "font",
+		Description: "commands for the font of welcome images",
+		Options: []discord.ApplicationCommandOptionSubCommand{
+			{
+				Name:        "upload",
+				Description: "upload a custom font for welcome images and use it",
+				Options: []discord.ApplicationCommandOption{
+					discord.ApplicationCommandOptionAttachment{
+						Name:        "font",
+						Description: "a ttf font file, most otf fonts can't be drawn",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "set",
+				Description: "set the font of welcome images",
+				Options: []discord.ApplicationCommandOption{
+					discord.ApplicationCommandOptionString{
+						Name:        "font",
+						Description: "the font to use, default uses the layout's font and custom uses the uploaded one",
+						Required:    true,
+						Choices:     fontChoices(k.assets),
+					},
+				},
+			},
+		},
+	}
+}
+
+func (k *kirby) onWelcomeFont(e *events.ApplicationCommandInteractionCreate) {
+	log := e.Client().Logger()
+	data := e.SlashCommandInteractionData()
+	q := queries.New(k.db)
+	guildID := e.GuildID().String()
+
+	switch *data.SubCommandName {
+	case "upload":
+		err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("uploading font!").SetEphemeral(true).Build())
+		if err != nil {
+			log.Errorf("failed to send message responding to welcome font upload")
+		}
+
+		w, err := q.GetWelcome(context.Background(), guildID)
+		if err != nil {
+			w = queries.Welcome(defaultWelcome(guildID))
+		}
+		raw, err := uploadedFont(context.Background(), data.Attachment("font"), fontSample(welcome(w)))
+		if err != nil {
+			_, err = e.Client().Rest().CreateFollowupMessage(e.ApplicationID(), e.Token(), discord.NewMessageCreateBuilder().SetContent("failed to use uploaded font: "+err.Error()).SetEphemeral(true).Build())
+			if err != nil {
+				log.Errorf("failed to send message responding to invalid font upload")
+			}
+			return
+		}
+
+		tx, err := k.db.Begin()
+		if err != nil {
+			log.Errorf("failed to begin transaction for welcome font upload: %v", err)
+			return
+		}
+		defer tx.Rollback()
+		q = q.WithTx(tx)
+		err = q.InsertWelcome(context.Background(), defaultWelcome(guildID))
+		if err != nil {
+			log.Errorf("failed to insert welcome for welcome font upload: %v", err)
+		}
+		err = q.UpsertWelcomeFont(context.Background(), queries.UpsertWelcomeFontParams{GuildID: guildID, Font: raw})
+		if err != nil {
+			log.Errorf("failed to upsert custom font for welcome font upload: %v", err)
+			return
+		}
+		err = q.SetWelcomeFontName(context.Background(), queries.SetWelcomeFontNameParams{GuildID: guildID, FontName: customFontName})
+		if err != nil {
+			log.Errorf("failed to set font for welcome font upload: %v", err)
+		}
+		err = tx.Commit()
+		if err != nil {
+			log.Errorf("failed to commit transaction for welcome font upload: %v", err)
+			return
+		}
+		k.fonts.Remove(guildID)
+	case "set":
+		name := data.String("font")
+		if name == "default" {
+			name = defaultFontName
+		}
+		if name == customFontName {
+			_, err := q.GetWelcomeFont(context.Background(), guildID)
+			if err != nil {
+				err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("no custom font uploaded, use `/welcome font upload` first!").SetEphemeral(true).Build())
+				if err != nil {
+					log.Errorf("failed to send message responding to missing custom font")
+				}
+				return
+			}
+		}
+
+		err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("setting welcome font!").SetEphemeral(true).Build())
+		if err != nil {
+			log.Errorf("failed to send message responding to welcome font set")
+		}
+		err = q.InsertWelcome(context.Background(), defaultWelcome(guildID))
+		if err != nil {
+			log.Errorf("failed to insert welcome for welcome font set: %v", err)
+		}
+		err = q.SetWelcomeFontName(context.Background(), queries.SetWelcomeFontNameParams{GuildID: guildID, FontName: name})
+		if err != nil {
+			log.Errorf("failed to set font for welcome font set: %v", err)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package discord
+
+import (
+	"context"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/events"
+	"github.com/disgoorg/json"
+	"github.com/disgoorg/snowflake/v2"
+
+	"github.com/ftqo/kirby/database/queries"
+)
+
+type goodbye = queries.InsertGoodbyeParams
+
+func (k *kirby) goodbyeCommand() command {
+	return command{
+		def: discord.SlashCommandCreate{
+			Name:                     "goodbye",
+			Description:              "several commands for setting up goodbye messages",
+			DefaultMemberPermissions: json.NewNullablePtr(discord.PermissionManageGuild),
+			Options: []discord.ApplicationCommandOption{
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "set",
+					Description: "set goodbye message options. texts are templates, see /welcome templates",
+					Options: []discord.ApplicationCommandOption{
+						discord.ApplicationCommandOptionChannel{
+							Name:        "channel",
+							Description: "the channel to send goodbye messages in",
+							Required:    false,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "message",
+							Description: "the contents of the message",
+							Required:    false,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "image_title",
+							Description: "the message in the top row of the image",
+							Required:    false,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "image_subtitle",
+							Description: "the message in the bottom row of the image",
+							Required:    false,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "type",
+							Description: "the type of message (plain, embed, or image) for the goodbye message",
+							Required:    false,
+							Choices:     messageTypeChoices,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "image",
+							Description: "the background image for the goodbye message",
+							Choices:     imageChoices,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "embed_title",
+							Description: "the title of the embed",
+							Required:    false,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "embed_description",
+							Description: "the description of the embed",
+							Required:    false,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "embed_color",
+							Description: "the color of the embed as a hex code (e.g. #f7a8c4)",
+							Required:    false,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "embed_footer",
+							Description: "the footer of the embed",
+							Required:    false,
+						},
+						discord.ApplicationCommandOptionBool{
+							Name:        "embed_timestamp",
+							Description: "whether to show the time of leaving in the embed",
+							Required:    false,
+						},
+					},
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "simulate",
+					Description: "simulate a goodbye message",
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "reset",
+					Description: "reset all goodbye settings to default",
+				},
+			},
+		},
+		handler: func(e *events.ApplicationCommandInteractionCreate) {
+			log := e.Client().Logger()
+			data := e.SlashCommandInteractionData()
+			q := queries.New(k.db)
+
+			switch *data.SubCommandName {
+			case "set":
+				var embedColor int32
+				embedColorHex, setEmbedColor := data.OptString("embed_color")
+				if setEmbedColor {
+					var err error
+					embedColor, err = parseHexColor(embedColorHex)
+					if err != nil {
+						err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("invalid embed color, use a hex code like `#f7a8c4`!").SetEphemeral(true).Build())
+						if err != nil {
+							log.Errorf("failed to send message responding to invalid embed color")
+						}
+						return
+					}
+				}
+
+				if err := validateTemplates(data.OptString, "message", "image_title", "image_subtitle", "embed_title", "embed_description", "embed_footer"); err != nil {
+					err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent(err.Error()).SetEphemeral(true).Build())
+					if err != nil {
+						log.Errorf("failed to send message responding to invalid goodbye template")
+					}
+					return
+				}
+
+				err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("setting goodbye config!").SetEphemeral(true).Build())
+				if err != nil {
+					log.Errorf("failed to send message responding to goodbye set")
+				}
+
+				tx, err := k.db.Begin()
+				if err != nil {
+					log.Errorf("failed to begin transaction for goodbye set: %v", err)
+					return
+				}
+				q = q.WithTx(tx)
+				gid := e.GuildID().String()
+				err = q.InsertGoodbye(context.Background(), defaultGoodbye(gid))
+				if err != nil {
+					log.Errorf("failed to insert default goodbye for goodbye set: %v", err)
+				}
+				if channel, ok := data.OptChannel("channel"); ok {
+					err = q.SetGoodbyeChannel(context.Background(), queries.SetGoodbyeChannelParams{GuildID: gid, ChannelID: channel.ID.String()})
+					if err != nil {
+						log.Errorf("failed to set channel for goodbye set: %v", err)
+					}
+				}
+				if message, ok := data.OptString("message"); ok {
+					err = q.SetGoodbyeMessageText(context.Background(), queries.SetGoodbyeMessageTextParams{GuildID: gid, MessageText: message})
+					if err != nil {
+						log.Errorf("failed to set message for goodbye set: %v", err)
+					}
+				}
+				if title, ok := data.OptString("image_title"); ok {
+					err = q.SetGoodbyeImageTitle(context.Background(), queries.SetGoodbyeImageTitleParams{GuildID: gid, ImageTitle: title})
+					if err != nil {
+						log.Errorf("failed to set image title for goodbye set: %v", err)
+					}
+				}
+				if subtitle, ok := data.OptString("image_subtitle"); ok {
+					err = q.SetGoodbyeImageSubtitle(context.Background(), queries.SetGoodbyeImageSubtitleParams{GuildID: gid, ImageSubtitle: subtitle})
+					if err != nil {
+						log.Errorf("failed to set image subtitle for goodbye set: %v", err)
+					}
+				}
+				if image, ok := data.OptString("image"); ok {
+					err = q.SetGoodbyeImageName(context.Background(), queries.SetGoodbyeImageNameParams{GuildID: gid, ImageName: image})
+					if err != nil {
+						log.Errorf("failed to set image for goodbye set: %v", err)
+					}
+				}
+				if typ, ok := data.OptString("type"); ok {
+					err = q.SetGoodbyeMessageType(context.Background(), queries.SetGoodbyeMessageTypeParams{GuildID: gid, MessageType: typ})
+					if err != nil {
+						log.Errorf("failed to set type for goodbye set: %v", err)
+					}
+				}
+				if title, ok := data.OptString("embed_title"); ok {
+					err = q.SetGoodbyeEmbedTitle(context.Background(), queries.SetGoodbyeEmbedTitleParams{GuildID: gid, EmbedTitle: title})
+					if err != nil {
+						log.Errorf("failed to set embed title for goodbye set: %v", err)
+					}
+				}
+				if description, ok := data.OptString("embed_description"); ok {
+					err = q.SetGoodbyeEmbedDescription(context.Background(), queries.SetGoodbyeEmbedDescriptionParams{GuildID: gid, EmbedDescription: description})
+					if err != nil {
+						log.Errorf("failed to set embed description for goodbye set: %v", err)
+					}
+				}
+				if setEmbedColor {
+					err = q.SetGoodbyeEmbedColor(context.Background(), queries.SetGoodbyeEmbedColorParams{GuildID: gid, EmbedColor: embedColor})
+					if err != nil {
+						log.Errorf("failed to set embed color for goodbye set: %v", err)
+					}
+				}
+				if footer, ok := data.OptString("embed_footer"); ok {
+					err = q.SetGoodbyeEmbedFooter(context.Background(), queries.SetGoodbyeEmbedFooterParams{GuildID: gid, EmbedFooter: footer})
+					if err != nil {
+						log.Errorf("failed to set embed footer for goodbye set: %v", err)
+					}
+				}
+				if timestamp, ok := data.OptBool("embed_timestamp"); ok {
+					err = q.SetGoodbyeEmbedTimestamp(context.Background(), queries.SetGoodbyeEmbedTimestampParams{GuildID: gid, EmbedTimestamp: timestamp})
+					if err != nil {
+						log.Errorf("failed to set embed timestamp for goodbye set: %v", err)
+					}
+				}
+				err = tx.Commit()
+				if err != nil {
+					log.Errorf("failed to commit transaction for goodbye set: %v", err)
+				}
+
+			case "simulate":
+				gb, err := q.GetGoodbye(context.Background(), e.GuildID().String())
+				if err != nil {
+					e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("goodbye channel not set, use `/goodbye set` and pick a channel!").SetEphemeral(true).Build())
+					q.InsertGoodbye(context.Background(), defaultGoodbye(e.GuildID().String()))
+					return
+				}
+				if len(gb.ChannelID) == 0 {
+					e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("goodbye channel not set, use `/goodbye set` and pick a channel!").SetEphemeral(true).Build())
+					return
+				}
+
+				err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("simulating goodbye!").SetEphemeral(true).Build())
+				if err != nil {
+					log.Errorf("failed to send message responding to goodbye simulate")
+				}
+
+				g, ok := e.Client().Caches().Guild(*e.GuildID())
+				if !ok {
+					rg, err := e.Client().Rest().GetGuild(*e.GuildID(), true)
+					if err != nil {
+						log.Errorf("failed to get guild from api for simulation: %v", err)
+						return
+					}
+					g = rg.Guild
+					g.MemberCount = g.ApproximateMemberCount
+				}
+
+				wr := newWelcomeReplace(e.Member().Member, g)
+
+				w := goodbyeWelcome(gb)
+				message := k.generateWelcomeMessage(log, w, wr)
+				channel, err := snowflake.Parse(gb.ChannelID)
+				if err != nil {
+					log.Errorf("failed to parse channel snowflake from channel id: %v", err)
+					return
+				}
+				_, err = e.Client().Rest().CreateMessage(channel, message)
+				if err != nil {
+					log.Errorf("failed to send simulated goodbye message: %v", err)
+				}
+			case "reset":
+				tx, err := k.db.Begin()
+				if err != nil {
+					log.Errorf("failed to begin transaction for goodbye reset: %v", err)
+					return
+				}
+				q = q.WithTx(tx)
+				err = q.DeleteGoodbye(context.Background(), e.GuildID().String())
+				if err != nil {
+					log.Errorf("failed to delete goodbye for goodbye reset: %v", err)
+				}
+				err = q.InsertGoodbye(context.Background(), defaultGoodbye(e.GuildID().String()))
+				if err != nil {
+					log.Errorf("failed to insert default goodbye for goodbye reset: %v", err)
+				}
+				err = tx.Commit()
+				if err != nil {
+					log.Errorf("failed to commit transaction for goodbye reset: %v", err)
+				}
+
+				err = e.CreateMessage(discord.NewMessageCreateBuilder().SetContent("reset goodbye config!").SetEphemeral(true).Build())
+				if err != nil {
+					log.Errorf("failed to send message responding to goodbye reset")
+				}
+			}
+		},
+	}
+}
+
+// goodbyeWelcome converts a goodbye into a welcome so it can go through the same rendering
+func goodbyeWelcome(g queries.Goodbye) welcome {
+	w := defaultWelcome(g.GuildID)
+	w.ChannelID = g.ChannelID
+	w.MessageType = g.MessageType
+	w.MessageText = g.MessageText
+	w.ImageName = g.ImageName
+	w.ImageTitle = g.ImageTitle
+	w.ImageSubtitle = g.ImageSubtitle
+	w.EmbedTitle = g.EmbedTitle
+	w.EmbedDescription = g.EmbedDescription
+	w.EmbedColor = g.EmbedColor
+	w.EmbedFooter = g.EmbedFooter
+	w.EmbedTimestamp = g.EmbedTimestamp
+	return w
+}
+
+func defaultGoodbye(gid string) goodbye {
+	return goodbye{
+		GuildID:       gid,
+		ChannelID:     "",
+		MessageType:   "image",
+		MessageText:   "goodbye %username%, we'll miss you :(",
+		ImageName:     "sleepy",
+		ImageTitle:    "%username% left the server",
+		ImageSubtitle: "we're down to %members% members",
+
+		EmbedTitle:       "%username% left the server",
+		EmbedDescription: "%guild% is down to %members% members",
+		EmbedColor:       0x8a9bd4,
+		EmbedFooter:      "",
+		EmbedTimestamp:   true,
+	}
+}
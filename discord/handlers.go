@@ -3,11 +3,11 @@ package discord
 import (
 	"context"
 	"math"
+	"time"
 
 	"github.com/disgoorg/disgo/discord"
 	"github.com/disgoorg/disgo/events"
 	"github.com/disgoorg/disgo/gateway"
-	"github.com/disgoorg/disgo/rest/route"
 	"github.com/disgoorg/snowflake/v2"
 	"github.com/ftqo/kirby/database/queries"
 )
@@ -16,7 +16,9 @@ func (k *kirby) onGuildMemberJoin(e *events.GuildMemberJoin) {
 	log := e.Client().Logger()
 	q := queries.New(k.db)
 
-	g, ok := e.Client().Caches().Guilds().Get(e.GuildID)
+	go k.assignJoinRoles(e.Client(), e.GuildID, e.Member)
+
+	g, ok := e.Client().Caches().Guild(e.GuildID)
 	if !ok {
 		rg, err := e.Client().Rest().GetGuild(e.GuildID, true)
 		if err != nil {
@@ -26,6 +28,9 @@ func (k *kirby) onGuildMemberJoin(e *events.GuildMemberJoin) {
 		g.MemberCount = g.ApproximateMemberCount
 	}
 
+	wr := newWelcomeReplace(e.Member, g)
+	go k.celebrateMilestone(e.Client(), e.GuildID, wr)
+
 	w, err := q.GetWelcome(context.Background(), e.GuildID.String())
 	if err != nil {
 		log.Warnf("failed to get guild welcome from database: %v", err)
@@ -35,29 +40,141 @@ func (k *kirby) onGuildMemberJoin(e *events.GuildMemberJoin) {
 		}
 		return
 	}
-	if len(w.ChannelID) == 0 {
+	if len(w.ChannelID) == 0 && !w.DmEnabled {
+		return
+	}
+	v := k.withVariant(log, welcome(w))
+	var wc snowflake.ID
+	if len(w.ChannelID) != 0 {
+		wc, err = snowflake.Parse(w.ChannelID)
+		if err != nil {
+			log.Error("failed to parse channel ID: ", err)
+			return
+		}
+	}
+	var j *renderJob
+	if wc != 0 {
+		j = &renderJob{
+			client:  e.Client(),
+			kind:    "welcome",
+			guildID: e.GuildID,
+			channel: wc,
+			w:       v,
+			wr:      wr,
+		}
+	}
+	if w.DmEnabled {
+		var fallback *renderJob
+		if w.DmFallback {
+			fallback = j
+		}
+		k.sendDM(e.Client(), e.GuildID, e.Member.User.ID, v, wr, fallback, nil)
+		if fallback != nil {
+			// the channel welcome is only sent if the dm can't be
+			return
+		}
+	}
+	if j != nil {
+		k.welcomeInChannel(j)
+	}
+}
+
+// welcomeInChannel sends a channel welcome, holding it back to batch with other joins if the guild batches them
+func (k *kirby) welcomeInChannel(j *renderJob) {
+	if j.w.BatchThreshold > 0 {
+		j.client.Logger().Debugf("holding welcome in guild %s to send with other joins", j.guildID)
+		k.batcher.hold(j, int(j.w.BatchThreshold), time.Duration(j.w.BatchWindow)*time.Second)
+		return
+	}
+	k.send(j)
+}
+
+func (k *kirby) onGuildMemberLeave(e *events.GuildMemberLeave) {
+	log := e.Client().Logger()
+	q := queries.New(k.db)
+
+	g, ok := e.Client().Caches().Guild(e.GuildID)
+	if !ok {
+		rg, err := e.Client().Rest().GetGuild(e.GuildID, true)
+		if err != nil {
+			log.Errorf("failed to get guild from api for goodbye: %v", err)
+			return
+		}
+		g = rg.Guild
+		g.MemberCount = g.ApproximateMemberCount
+	}
+
+	gb, err := q.GetGoodbye(context.Background(), e.GuildID.String())
+	if err != nil {
+		log.Warnf("failed to get guild goodbye from database: %v", err)
+		err = q.InsertGoodbye(context.Background(), defaultGoodbye(g.ID.String()))
+		if err != nil {
+			log.Errorf("failed to insert goodbye into database: %v", err)
+		}
 		return
 	}
-	wr := welcomeReplace{
-		mention:   e.Member.User.Mention(),
-		nickname:  e.Member.User.Username,
-		username:  e.Member.User.Tag(),
-		avatarURL: e.Member.User.EffectiveAvatarURL(discord.WithSize(512), discord.WithFormat(route.PNG)),
-		members:   g.MemberCount,
-		guildName: g.Name,
+	if len(gb.ChannelID) == 0 {
+		return
 	}
-	wc, err := snowflake.Parse(w.ChannelID)
+	// the member may not be cached, but the user always comes with the event
+	m := e.Member
+	m.User = e.User
+	wr := newWelcomeReplace(m, g)
+	gc, err := snowflake.Parse(gb.ChannelID)
 	if err != nil {
 		log.Error("failed to parse channel ID: ", err)
 		return
 	}
-	go func() {
-		welcome := generateWelcomeMessage(log, welcome(w), wr, k.assets)
-		_, err = e.Client().Rest().CreateMessage(wc, welcome)
-		if err != nil {
-			log.Error("failed to send welcome message: ", err)
+	k.send(&renderJob{
+		client:  e.Client(),
+		kind:    "goodbye",
+		guildID: e.GuildID,
+		channel: gc,
+		w:       goodbyeWelcome(gb),
+		wr:      wr,
+	})
+}
+
+// send queues image messages to be rendered, sending anything else right away
+func (k *kirby) send(j *renderJob) {
+	if j.w.MessageType == "image" {
+		k.queue.enqueue(j)
+		return
+	}
+	go k.process(j)
+}
+
+// process generates and sends a queued message
+func (k *kirby) process(j *renderJob) {
+	log := j.client.Logger()
+	var msg discord.MessageCreate
+	switch {
+	case j.milestone:
+		msg = k.generateMilestoneMessage(log, j.w, j.wr)
+	case len(j.batch) != 0:
+		msg = k.generateCollageMessage(log, j.w, j.batch)
+	default:
+		msg = k.generateWelcomeMessage(log, j.w, j.wr)
+	}
+	if j.dmUser != 0 {
+		k.deliverDM(log, j, msg)
+		return
+	}
+	if j.kind == "welcome" {
+		newcomer := j.wr.userID
+		if len(j.batch) != 0 {
+			newcomer = 0
 		}
-	}()
+		msg.Components = k.welcomeComponents(log, j.w, newcomer)
+	}
+	if len(j.coalesced) != 0 {
+		// the queue was full, so these members share this message instead of getting their own
+		msg.Content = withCoalesced(msg.Content, j)
+	}
+	_, err := j.client.Rest().CreateMessage(j.channel, msg)
+	if err != nil {
+		log.Errorf("failed to send %s message: %v", j.kind, err)
+	}
 }
 
 func (k *kirby) onReady(e *events.Ready) {
@@ -65,17 +182,12 @@ func (k *kirby) onReady(e *events.Ready) {
 	log.Info("kirby connected to discord")
 
 	var min int64 = math.MinInt64
-	err := e.Client().SetPresence(context.Background(), gateway.MessageDataPresenceUpdate{
-		Since: &min,
-		Activities: []discord.Activity{
-			{
-				Name: "the stars",
-				Type: discord.ActivityTypeWatching,
-			},
-		},
-		Status: discord.OnlineStatusOnline,
-		AFK:    false,
-	})
+	err := e.Client().SetPresence(context.Background(),
+		gateway.WithSince(&min),
+		gateway.WithWatchingActivity("the stars"),
+		gateway.WithOnlineStatus(discord.OnlineStatusOnline),
+		gateway.WithAfk(false),
+	)
 	if err != nil {
 		log.Errorf("failed to set status on ready")
 	}
@@ -0,0 +1,362 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/events"
+	"github.com/disgoorg/json"
+	"github.com/disgoorg/log"
+	"github.com/disgoorg/snowflake/v2"
+
+	"github.com/ftqo/kirby/card"
+	"github.com/ftqo/kirby/database/queries"
+)
+
+const (
+	milestoneLayout      = "milestone"
+	defaultMilestoneText = "🎉 {{.Guild}} just reached {{number .Members}} members! {{.Mention}}, you're our {{ordinal .Members}}!"
+	// how far past a milestone a join can be and still celebrate it, since the member count can jump when members join at once
+	milestoneSlack = 5
+	// how many reached milestones /milestone list shows
+	maxListedMilestones = 10
+)
+
+var minMilestoneEvery, maxMilestoneEvery = 0, 1000000
+
+func defaultMilestone(gid string) queries.InsertMilestoneParams {
+	return queries.InsertMilestoneParams{
+		GuildID:     gid,
+		ChannelID:   "",
+		Every:       0,
+		Numbers:     "",
+		MessageText: defaultMilestoneText,
+	}
+}
+
+// parseMilestoneNumbers parses a list of member counts separated by commas or spaces
+func parseMilestoneNumbers(s string) ([]int, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	numbers := make([]int, 0, len(fields))
+	for _, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%q is not a member count", f)
+		}
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	return numbers, nil
+}
+
+// crossedMilestone returns the highest milestone a guild has just reached with members, if any
+func crossedMilestone(m queries.Milestone, members int) (int, bool) {
+	best := 0
+	if m.Every > 0 {
+		best = members / int(m.Every) * int(m.Every)
+	}
+	numbers, _ := parseMilestoneNumbers(m.Numbers)
+	for _, n := range numbers {
+		if n <= members && n > best {
+			best = n
+		}
+	}
+	return best, best > 0 && members-best < milestoneSlack
+}
+
+// celebrateMilestone queues a celebration if a join brought a guild to one of its milestones for the first time
+func (k *kirby) celebrateMilestone(client bot.Client, guildID snowflake.ID, wr welcomeReplace) {
+	log := client.Logger()
+	q := queries.New(k.db)
+	m, err := q.GetMilestone(context.Background(), guildID.String())
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Errorf("failed to get milestones from database: %v", err)
+		}
+		return
+	}
+	members, ok := crossedMilestone(m, wr.members)
+	if !ok {
+		return
+	}
+	// guilds can set milestones without ever setting up welcomes
+	var w welcome
+	gw, err := q.GetWelcome(context.Background(), guildID.String())
+	switch {
+	case err == nil:
+		w = welcome(gw)
+	case errors.Is(err, sql.ErrNoRows):
+		w = defaultWelcome(guildID.String())
+	default:
+		log.Errorf("failed to get welcome for milestone from database: %v", err)
+		return
+	}
+	channelID := m.ChannelID
+	if channelID == "" {
+		channelID = w.ChannelID
+	}
+	if channelID == "" {
+		log.Debugf("guild %s reached %d members but has no milestone or welcome channel", guildID, members)
+		return
+	}
+	channel, err := snowflake.Parse(channelID)
+	if err != nil {
+		log.Errorf("failed to parse milestone channel ID: %v", err)
+		return
+	}
+
+	// only the first join to reach a milestone celebrates it, even if members leave and rejoin around it
+	n, err := q.InsertReachedMilestone(context.Background(), queries.InsertReachedMilestoneParams{GuildID: guildID.String(), Members: int32(members)})
+	if err != nil {
+		log.Errorf("failed to insert reached milestone: %v", err)
+		return
+	}
+	if n == 0 {
+		return
+	}
+
+	w.MessageType = "image"
+	w.MessageText = m.MessageText
+	wr.members = members
+	k.send(&renderJob{
+		client:    client,
+		kind:      "milestone",
+		guildID:   guildID,
+		channel:   channel,
+		w:         w,
+		wr:        wr,
+		milestone: true,
+	})
+}
+
+// generateMilestoneMessage celebrates a member count with the milestone layout, using the guild's welcome background and colors
+func (k *kirby) generateMilestoneMessage(log log.Logger, w welcome, wr welcomeReplace) discord.MessageCreate {
+	log.Trace("generating milestone message")
+	var msg discord.MessageCreate
+
+	text, err := executeTemplate("message", w.MessageText, wr.templateData(timezone(w.Timezone)))
+	if err != nil {
+		log.Warnf("failed to execute milestone template: %v", err)
+		text = w.MessageText
+	}
+	msg.Content = text
+	// the render queue was full, so the card is left out rather than rendered outside of it
+	if w.MessageType == "plain" {
+		return msg
+	}
+
+	l, ok := k.assets.Layouts[milestoneLayout]
+	if !ok {
+		log.Errorf("milestone layout is missing")
+		return msg
+	}
+	pfp, err := k.avatars.imageOrDefault(context.Background(), wr.avatarHash, wr.avatarURL)
+	if err != nil {
+		log.Warnf("failed to get avatar for milestone, using default: %v", err)
+	}
+	base, err := k.cardBase(log, w, l)
+	if err != nil {
+		log.Errorf("failed to render milestone card background: %v", err)
+		return msg
+	}
+	img, err := card.Render(k.assets, l, card.Data{
+		Base:   base.image,
+		Theme:  base.theme,
+		Font:   k.welcomeFont(log, w),
+		Faces:  k.faces,
+		Avatar: pfp,
+		Texts: map[string]string{
+			"count":    number(wr.members),
+			"title":    plural(wr.members, "member", "members") + " in " + wr.guildName,
+			"subtitle": "thanks for joining, " + wr.nickname + "!",
+			"guild":    wr.guildName,
+			"members":  strconv.Itoa(wr.members),
+		},
+	})
+	if err != nil {
+		log.Errorf("failed to render milestone card: %v", err)
+		return msg
+	}
+
+	enc := k.encoding(w)
+	raw, err := card.Encode(img, enc)
+	if err != nil {
+		log.Errorf("failed to encode milestone card: %v", err)
+		return msg
+	}
+	msg.Files = append(msg.Files, &discord.File{
+		Name:   fmt.Sprintf("milestone_%d%s", wr.members, card.Extension(enc.Format)),
+		Reader: bytes.NewReader(raw),
+	})
+	return msg
+}
+
+func (k *kirby) milestoneCommand() command {
+	return command{
+		def: discord.SlashCommandCreate{
+			Name:                     "milestone",
+			Description:              "several commands for celebrating member count milestones",
+			DefaultMemberPermissions: json.NewNullablePtr(discord.PermissionManageGuild),
+			Options: []discord.ApplicationCommandOption{
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "set",
+					Description: "set which member counts to celebrate and how",
+					Options: []discord.ApplicationCommandOption{
+						discord.ApplicationCommandOptionChannel{
+							Name:        "channel",
+							Description: "the channel to celebrate in, the welcome channel by default",
+							Required:    false,
+						},
+						discord.ApplicationCommandOptionInt{
+							Name:        "every",
+							Description: "celebrate every this many members, like 100 or 1000, 0 to turn off",
+							Required:    false,
+							MinValue:    &minMilestoneEvery,
+							MaxValue:    &maxMilestoneEvery,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "numbers",
+							Description: "specific member counts to celebrate, like 500 2500 10000, or none",
+							Required:    false,
+						},
+						discord.ApplicationCommandOptionString{
+							Name:        "message",
+							Description: "the contents of the celebration message, see /welcome templates",
+							Required:    false,
+						},
+					},
+				},
+				discord.ApplicationCommandOptionSubCommand{
+					Name:        "list",
+					Description: "show the milestones and which have been reached",
+				},
+			},
+		},
+		handler: func(e *events.ApplicationCommandInteractionCreate) {
+			log := e.Client().Logger()
+			data := e.SlashCommandInteractionData()
+			q := queries.New(k.db)
+			guildID := e.GuildID().String()
+
+			reply := func(content string) {
+				err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent(content).SetEphemeral(true).Build())
+				if err != nil {
+					log.Errorf("failed to send message responding to milestone %s: %v", *data.SubCommandName, err)
+				}
+			}
+
+			switch *data.SubCommandName {
+			case "set":
+				numbers, setNumbers := data.OptString("numbers")
+				if setNumbers {
+					if strings.EqualFold(strings.TrimSpace(numbers), "none") {
+						numbers = ""
+					}
+					parsed, err := parseMilestoneNumbers(numbers)
+					if err != nil {
+						reply("invalid numbers: " + err.Error() + ", use member counts like `500 2500 10000`!")
+						return
+					}
+					normalized := make([]string, len(parsed))
+					for i, n := range parsed {
+						normalized[i] = strconv.Itoa(n)
+					}
+					numbers = strings.Join(normalized, ",")
+				}
+				if err := validateTemplates(data.OptString, "message"); err != nil {
+					reply(err.Error())
+					return
+				}
+				reply("setting milestone config!")
+
+				tx, err := k.db.Begin()
+				if err != nil {
+					log.Errorf("failed to begin transaction for milestone set: %v", err)
+					return
+				}
+				defer tx.Rollback()
+				q = q.WithTx(tx)
+				err = q.InsertMilestone(context.Background(), defaultMilestone(guildID))
+				if err != nil {
+					log.Errorf("failed to insert default milestone for milestone set: %v", err)
+				}
+				if channel, ok := data.OptChannel("channel"); ok {
+					err = q.SetMilestoneChannel(context.Background(), queries.SetMilestoneChannelParams{GuildID: guildID, ChannelID: channel.ID.String()})
+					if err != nil {
+						log.Errorf("failed to set channel for milestone set: %v", err)
+					}
+				}
+				if every, ok := data.OptInt("every"); ok {
+					err = q.SetMilestoneEvery(context.Background(), queries.SetMilestoneEveryParams{GuildID: guildID, Every: int32(every)})
+					if err != nil {
+						log.Errorf("failed to set every for milestone set: %v", err)
+					}
+				}
+				if setNumbers {
+					err = q.SetMilestoneNumbers(context.Background(), queries.SetMilestoneNumbersParams{GuildID: guildID, Numbers: numbers})
+					if err != nil {
+						log.Errorf("failed to set numbers for milestone set: %v", err)
+					}
+				}
+				if message, ok := data.OptString("message"); ok {
+					err = q.SetMilestoneMessageText(context.Background(), queries.SetMilestoneMessageTextParams{GuildID: guildID, MessageText: message})
+					if err != nil {
+						log.Errorf("failed to set message for milestone set: %v", err)
+					}
+				}
+				err = tx.Commit()
+				if err != nil {
+					log.Errorf("failed to commit transaction for milestone set: %v", err)
+				}
+			case "list":
+				m, err := q.GetMilestone(context.Background(), guildID)
+				if err != nil && !errors.Is(err, sql.ErrNoRows) {
+					log.Errorf("failed to get milestones: %v", err)
+					reply("failed to get milestones!")
+					return
+				}
+				if m.Every == 0 && m.Numbers == "" {
+					reply("no milestones set, use `/milestone set` to celebrate every 100 members or specific counts!")
+					return
+				}
+				var lines []string
+				if m.Every > 0 {
+					lines = append(lines, fmt.Sprintf("celebrating every %s members", number(int(m.Every))))
+				}
+				if m.Numbers != "" {
+					lines = append(lines, "celebrating at "+strings.ReplaceAll(m.Numbers, ",", ", ")+" members")
+				}
+				if m.ChannelID != "" {
+					lines = append(lines, "in <#"+m.ChannelID+">")
+				} else {
+					lines = append(lines, "in the welcome channel")
+				}
+				reached, err := q.GetReachedMilestones(context.Background(), guildID)
+				if err != nil {
+					log.Errorf("failed to get reached milestones: %v", err)
+				}
+				if len(reached) > 0 {
+					if len(reached) > maxListedMilestones {
+						reached = reached[:maxListedMilestones]
+					}
+					counts := make([]string, len(reached))
+					for i, r := range reached {
+						counts[i] = number(int(r))
+					}
+					lines = append(lines, "reached: "+strings.Join(counts, ", "))
+				}
+				reply(strings.Join(lines, "\n"))
+			}
+		},
+	}
+}
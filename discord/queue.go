@@ -0,0 +1,271 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/disgo/bot"
+	"github.com/disgoorg/log"
+	"github.com/disgoorg/snowflake/v2"
+
+	"github.com/ftqo/kirby/config"
+)
+
+const (
+	overflowDrop     = "drop"
+	overflowPlain    = "plain"
+	overflowCoalesce = "coalesce"
+
+	queueStatsInterval = 5 * time.Minute
+	// members coalesced into one message at most, far more than can be mentioned in it, the rest are dropped
+	maxCoalesced = 500
+)
+
+// renderJob is a welcome or goodbye card waiting to be rendered and sent
+type renderJob struct {
+	client bot.Client
+	// welcome, goodbye, welcome dm, or milestone
+	kind    string
+	guildID snowflake.ID
+	channel snowflake.ID
+	// set for direct messages, which go to this user instead of channel
+	dmUser snowflake.ID
+	// the channel welcome sent instead of a direct message that can't be delivered
+	fallback *renderJob
+	// called when a direct message can't be delivered
+	undelivered func()
+	w           welcome
+	wr          welcomeReplace
+	// members that joined during a raid, welcomed together with a collage instead of wr
+	batch []welcomeReplace
+	// members that joined while the queue was full, mentioned in this job's message
+	coalesced []welcomeReplace
+	// celebrates reaching wr.members instead of welcoming wr
+	milestone bool
+	queued    time.Time
+}
+
+// renderQueue runs render jobs on a fixed number of workers, taking turns between guilds
+type renderQueue struct {
+	log     log.Logger
+	cfg     config.RenderConfig
+	process func(j *renderJob)
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	guilds map[snowflake.ID][]*renderJob
+	// guilds with pending jobs, in the order they will be served
+	order  []snowflake.ID
+	depth  int
+	closed bool
+
+	stats queueStats
+}
+
+type queueStats struct {
+	processed int
+	dropped   int
+	degraded  int
+	coalesced int
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+func newRenderQueue(log log.Logger, cfg config.RenderConfig, process func(j *renderJob)) *renderQueue {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.MaxQueue <= 0 {
+		cfg.MaxQueue = 200
+	}
+	if cfg.MaxQueuePerGuild <= 0 {
+		cfg.MaxQueuePerGuild = 20
+	}
+	switch cfg.Overflow {
+	case overflowDrop, overflowPlain, overflowCoalesce:
+	default:
+		log.Warnf("unknown render queue overflow policy %q, using %s", cfg.Overflow, overflowCoalesce)
+		cfg.Overflow = overflowCoalesce
+	}
+	q := &renderQueue{
+		log:     log,
+		cfg:     cfg,
+		process: process,
+		guilds:  make(map[snowflake.ID][]*renderJob),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// run starts the workers and blocks until ctx is done
+func (q *renderQueue) run(ctx context.Context) {
+	q.log.Infof("starting %d render workers", q.cfg.Workers)
+	wg := sync.WaitGroup{}
+	for i := 0; i < q.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work()
+		}()
+	}
+
+	ticker := time.NewTicker(queueStatsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			q.logStats()
+		case <-ctx.Done():
+			q.mu.Lock()
+			q.closed = true
+			if q.depth > 0 {
+				q.log.Warnf("render queue closing with %d jobs left", q.depth)
+			}
+			q.mu.Unlock()
+			q.cond.Broadcast()
+			wg.Wait()
+			return
+		}
+	}
+}
+
+func (q *renderQueue) work() {
+	for {
+		j := q.next()
+		if j == nil {
+			return
+		}
+		wait := time.Since(j.queued)
+		q.mu.Lock()
+		q.stats.processed++
+		q.stats.totalWait += wait
+		if wait > q.stats.maxWait {
+			q.stats.maxWait = wait
+		}
+		q.mu.Unlock()
+		q.log.Debugf("rendering job for guild %s after waiting %s", j.guildID, wait)
+		q.safeProcess(j)
+	}
+}
+
+// safeProcess processes a job, sending it as a plain message instead if rendering it panics, so one
+// guild's broken card can't take down every worker
+func (q *renderQueue) safeProcess(j *renderJob) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		q.log.Errorf("panic while processing %s job for guild %s: %v\n%s", j.kind, j.guildID, r, debug.Stack())
+		if j.w.MessageType == "plain" {
+			return
+		}
+		j.w.MessageType = "plain"
+		q.safeProcess(j)
+	}()
+	q.process(j)
+}
+
+// next blocks until there is a job, taking one from the guild whose turn it is
+func (q *renderQueue) next() *renderJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.order) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return nil
+	}
+
+	gid := q.order[0]
+	q.order = q.order[1:]
+	jobs := q.guilds[gid]
+	j := jobs[0]
+	if len(jobs) > 1 {
+		q.guilds[gid] = jobs[1:]
+		q.order = append(q.order, gid)
+	} else {
+		delete(q.guilds, gid)
+	}
+	q.depth--
+	return j
+}
+
+// enqueue adds a job, applying the overflow policy if the queue or the guild's share of it is full
+func (q *renderQueue) enqueue(j *renderJob) {
+	j.queued = time.Now()
+	q.mu.Lock()
+	jobs := q.guilds[j.guildID]
+	if q.depth < q.cfg.MaxQueue && len(jobs) < q.cfg.MaxQueuePerGuild {
+		if len(jobs) == 0 {
+			q.order = append(q.order, j.guildID)
+		}
+		q.guilds[j.guildID] = append(jobs, j)
+		q.depth++
+		q.mu.Unlock()
+		q.cond.Signal()
+		return
+	}
+
+	policy := q.cfg.Overflow
+	// direct messages are personal, so only channel messages of the same kind get coalesced
+	if policy == overflowCoalesce && len(jobs) != 0 && canCoalesce(jobs[len(jobs)-1], j) {
+		last := jobs[len(jobs)-1]
+		if len(last.coalesced) < maxCoalesced {
+			last.coalesced = append(last.coalesced, j.wr)
+			q.stats.coalesced++
+			q.mu.Unlock()
+			return
+		}
+		policy = overflowDrop
+	}
+	if policy == overflowDrop {
+		q.stats.dropped++
+		q.mu.Unlock()
+		q.log.Warnf("render queue full, dropped job for guild %s", j.guildID)
+		return
+	}
+	// either plain or coalescing without anything to coalesce into, milestones included
+	q.stats.degraded++
+	q.mu.Unlock()
+	q.log.Debugf("render queue full, sending plain message for guild %s", j.guildID)
+	j.w.MessageType = "plain"
+	go q.safeProcess(j)
+}
+
+// withCoalesced adds the mentions of j's coalesced members to content, as many as fit in a message
+func withCoalesced(content string, j *renderJob) string {
+	intro := fmt.Sprintf("\nalso %s ", j.kind)
+	room := maxContentLength - len(content) - len(intro) - len("!")
+	suffix := intro + mentionList(j.coalesced, room) + "!"
+	if len(content)+len(suffix) > maxContentLength {
+		// the message itself is nearly full, too full to even say how many members were left out
+		return content
+	}
+	return content + suffix
+}
+
+func canCoalesce(into, j *renderJob) bool {
+	return into.kind == j.kind && into.dmUser == 0 && j.dmUser == 0 && !into.milestone && !j.milestone
+}
+
+func (q *renderQueue) logStats() {
+	q.mu.Lock()
+	s := q.stats
+	depth := q.depth
+	q.stats = queueStats{}
+	q.mu.Unlock()
+
+	if s.processed == 0 && s.dropped == 0 && s.degraded == 0 && s.coalesced == 0 {
+		return
+	}
+	var avgWait time.Duration
+	if s.processed > 0 {
+		avgWait = s.totalWait / time.Duration(s.processed)
+	}
+	q.log.Infof("render queue: %d processed, %d dropped, %d degraded, %d coalesced, %s average wait, %s max wait, %d queued",
+		s.processed, s.dropped, s.degraded, s.coalesced, avgWait, s.maxWait, depth)
+}
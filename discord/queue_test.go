@@ -0,0 +1,97 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/disgoorg/log"
+
+	"github.com/ftqo/kirby/config"
+)
+
+func testLogger() log.Logger {
+	l := log.New(0)
+	l.SetLevel(log.LevelError)
+	return l
+}
+
+func testQueue(overflow string, process func(j *renderJob)) *renderQueue {
+	return newRenderQueue(testLogger(), config.RenderConfig{MaxQueue: 1, MaxQueuePerGuild: 1, Overflow: overflow}, process)
+}
+
+func TestQueueCoalescedMessageFits(t *testing.T) {
+	q := testQueue(overflowCoalesce, func(j *renderJob) {})
+	joins := 150
+	for i := 0; i < joins; i++ {
+		q.enqueue(&renderJob{
+			kind:    "welcome",
+			guildID: 1,
+			wr:      welcomeReplace{mention: fmt.Sprintf("<@%d>", 100000000000000000+i)},
+		})
+	}
+	j := q.next()
+	if got := len(j.coalesced); got != joins-1 {
+		t.Fatalf("coalesced %d joins, want %d", got, joins-1)
+	}
+
+	for _, content := range []string{"", "hi <@1>, welcome to dream land :)", strings.Repeat("a", maxContentLength-10)} {
+		got := withCoalesced(content, j)
+		if len(got) > maxContentLength {
+			t.Errorf("message with %d characters of content is %d characters, over the limit of %d", len(content), len(got), maxContentLength)
+		}
+		if !strings.HasPrefix(got, content) {
+			t.Errorf("message with %d characters of content lost its content", len(content))
+		}
+	}
+	if got := withCoalesced("", j); !strings.HasSuffix(got, "more!") {
+		t.Errorf("message doesn't say how many members were left out, it ends with %q", got[len(got)-20:])
+	}
+}
+
+func TestQueueCoalesceLimit(t *testing.T) {
+	q := testQueue(overflowCoalesce, func(j *renderJob) {})
+	for i := 0; i < maxCoalesced+10; i++ {
+		q.enqueue(&renderJob{kind: "welcome", guildID: 1})
+	}
+	j := q.next()
+	if got := len(j.coalesced); got != maxCoalesced {
+		t.Errorf("coalesced %d joins, want at most %d", got, maxCoalesced)
+	}
+	if q.stats.dropped != 10-1 {
+		t.Errorf("dropped %d joins, want %d", q.stats.dropped, 10-1)
+	}
+}
+
+func TestQueueDegradedMilestoneIsText(t *testing.T) {
+	degraded := make(chan *renderJob, 1)
+	q := testQueue(overflowPlain, func(j *renderJob) { degraded <- j })
+	q.enqueue(&renderJob{kind: "welcome", guildID: 1})
+	w := defaultWelcome("1")
+	w.MessageType = "image"
+	w.MessageText = "we hit {{.Members}} members!"
+	q.enqueue(&renderJob{kind: "milestone", guildID: 1, milestone: true, w: w, wr: welcomeReplace{members: 1000}})
+
+	j := <-degraded
+	// no assets, so this would panic if it tried to render the card
+	msg := (&kirby{}).generateMilestoneMessage(testLogger(), j.w, j.wr)
+	if len(msg.Files) != 0 {
+		t.Errorf("degraded milestone has %d files, want none", len(msg.Files))
+	}
+	if msg.Content != "we hit 1000 members!" {
+		t.Errorf("degraded milestone says %q", msg.Content)
+	}
+}
+
+func TestQueueRecoversFromPanics(t *testing.T) {
+	var types []string
+	q := testQueue(overflowCoalesce, func(j *renderJob) {
+		types = append(types, j.w.MessageType)
+		panic("broken font")
+	})
+	// panics even as a plain message, which is only tried once
+	q.safeProcess(&renderJob{kind: "welcome", guildID: 1, w: welcome{MessageType: "image"}})
+	if strings.Join(types, ",") != "image,plain" {
+		t.Errorf("processed as %v, want an image and then a plain message", types)
+	}
+}
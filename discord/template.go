@@ -0,0 +1,323 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+	// guilds can pick any timezone, even if the host has no zoneinfo
+	_ "time/tzdata"
+
+	"github.com/disgoorg/disgo/discord"
+)
+
+// templates can't make messages longer than discord allows in an embed description
+const maxTemplateOutput = 4096
+
+const defaultTimezone = "UTC"
+
+// legacyPlaceholders turns the old %placeholder% syntax into template actions,
+// so settings from before templates keep working
+var legacyPlaceholders = strings.NewReplacer(
+	"%mention%", "{{.Mention}}",
+	"%nickname%", "{{.Nickname}}",
+	"%username%", "{{.Username}}",
+	"%guild%", "{{.Guild}}",
+	"%members%", "{{.Members}}",
+)
+
+// templateData is what welcome templates can refer to
+type templateData struct {
+	Mention string
+	// the account's name, without a discriminator
+	Nickname string
+	// the account's name with its discriminator
+	Username string
+	// the member's nickname in the guild, or their account's name if they don't have one
+	DisplayName string
+	// the account's display name, or its name if it doesn't have one
+	GlobalName string
+	Guild      string
+	Members    int
+	Boosts     int
+	Bot        bool
+	// in the guild's timezone
+	Created time.Time
+	Joined  time.Time
+}
+
+var templateFuncs = template.FuncMap{
+	"number":  number,
+	"ordinal": ordinal,
+	"plural":  plural,
+	"age":     age,
+	"date": func(t time.Time) string {
+		return t.Format("January 2, 2006")
+	},
+	"time": func(t time.Time) string {
+		return t.Format("3:04 PM MST")
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// templateHelp describes what templates can use, for /welcome templates
+const templateHelp = "welcome texts are go templates, e.g. `welcome {{.Mention}}, you are our {{ordinal .Members}} member!`\n" +
+	"**fields:** `.Mention`, `.Nickname`, `.Username`, `.DisplayName`, `.GlobalName`, `.Guild`, `.Members`, `.Boosts`, `.Bot`, `.Created` (account creation), `.Joined`\n" +
+	"**functions:** `number 1234` → 1,234, `ordinal 1234` → 1,234th, `plural .Boosts \"boost\" \"boosts\"`, `age .Created` → 3 years, " +
+	"`date .Joined`, `time .Joined`, `upper`, `lower`, plus `eq`, `lt`, `gt`, `and`, `or`, and `not`\n" +
+	"**conditionals:** `{{if .Bot}}beep boop{{else}}hi!{{end}}`, `{{if gt .Boosts 0}}thanks for the boosts!{{end}}`\n" +
+	"the old `%mention%`, `%nickname%`, `%username%`, `%guild%`, and `%members%` placeholders still work"
+
+// newWelcomeReplace gathers what welcome messages need to know about a member and their guild
+func newWelcomeReplace(m discord.Member, g discord.Guild) welcomeReplace {
+	return welcomeReplace{
+		userID:            m.User.ID,
+		mention:           m.User.Mention(),
+		nickname:          m.User.Username,
+		username:          m.User.Tag(),
+		displayName:       m.EffectiveName(),
+		globalName:        m.User.EffectiveName(),
+		avatarURL:         m.User.EffectiveAvatarURL(discord.WithSize(512), discord.WithFormat(discord.ImageFormatPNG)),
+		avatarHash:        avatarHash(m.User),
+		animatedAvatarURL: animatedAvatarURL(m.User),
+		bot:               m.User.Bot,
+		created:           m.User.ID.Time(),
+		joined:            m.JoinedAt,
+		members:           g.MemberCount,
+		boosts:            g.PremiumSubscriptionCount,
+		guildName:         g.Name,
+	}
+}
+
+// templateData returns the data welcome templates are executed with
+func (wr welcomeReplace) templateData(loc *time.Location) templateData {
+	return templateData{
+		Mention:     wr.mention,
+		Nickname:    wr.nickname,
+		Username:    wr.username,
+		DisplayName: wr.displayName,
+		GlobalName:  wr.globalName,
+		Guild:       wr.guildName,
+		Members:     wr.members,
+		Boosts:      wr.boosts,
+		Bot:         wr.bot,
+		Created:     wr.created.In(loc),
+		Joined:      wr.joined.In(loc),
+	}
+}
+
+// sampleTemplateData stands in for a member when checking templates
+var sampleTemplateData = templateData{
+	Mention:     "<@0>",
+	Nickname:    "kirby",
+	Username:    "kirby#0000",
+	DisplayName: "kirby",
+	GlobalName:  "kirby",
+	Guild:       "dream land",
+	Members:     1234,
+	Boosts:      2,
+	Created:     time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC),
+	Joined:      time.Now(),
+}
+
+// parseTemplate parses a welcome text, refusing anything that could run for a long time
+func parseTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(legacyPlaceholders.Replace(text))
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Templates()) > 1 {
+		return nil, errors.New("defining templates isn't allowed")
+	}
+	if t.Tree == nil {
+		return t, nil
+	}
+	return t, checkTemplateNode(t.Tree.Root)
+}
+
+// checkTemplateNode makes sure a template has no loops, calls to other templates, or printf
+func checkTemplateNode(n parse.Node) error {
+	switch n := n.(type) {
+	case *parse.ActionNode:
+		return checkTemplateNode(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, c := range n.Cmds {
+			if err := checkTemplateNode(c); err != nil {
+				return err
+			}
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			if err := checkTemplateNode(a); err != nil {
+				return err
+			}
+		}
+	case *parse.IdentifierNode:
+		// widths like %999999d are allocated before limitedBuilder can stop them, and number covers formatting
+		if n.Ident == "printf" {
+			return errors.New("printf isn't allowed")
+		}
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, c := range n.Nodes {
+			if err := checkTemplateNode(c); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return checkTemplateBranch(n.BranchNode)
+	case *parse.WithNode:
+		return checkTemplateBranch(n.BranchNode)
+	case *parse.RangeNode:
+		return errors.New("range isn't allowed")
+	case *parse.TemplateNode:
+		return errors.New("calling other templates isn't allowed")
+	}
+	return nil
+}
+
+func checkTemplateBranch(b parse.BranchNode) error {
+	if err := checkTemplateNode(b.Pipe); err != nil {
+		return err
+	}
+	if err := checkTemplateNode(b.List); err != nil {
+		return err
+	}
+	return checkTemplateNode(b.ElseList)
+}
+
+// executeTemplate fills in a welcome text
+func executeTemplate(name, text string, d templateData) (string, error) {
+	t, err := parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var sb limitedBuilder
+	err = t.Execute(&sb, d)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// validateTemplate checks that a welcome text parses and runs, so mistakes show up when it is set instead of when someone joins
+func validateTemplate(name, text string) error {
+	_, err := executeTemplate(name, text, sampleTemplateData)
+	if err != nil {
+		// text/template's errors are written for programmers, so trim them down to the useful part
+		msg := strings.NewReplacer("template: "+name+":", "", "executing \""+name+"\" ", "", " in type discord.templateData", "").Replace(err.Error())
+		return fmt.Errorf("invalid %s: %s", strings.ReplaceAll(name, "_", " "), msg)
+	}
+	return nil
+}
+
+// validateTemplates checks the given template options of a command
+func validateTemplates(opt func(string) (string, bool), names ...string) error {
+	for _, name := range names {
+		if text, ok := opt(name); ok {
+			if err := validateTemplate(name, text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// timezone returns a guild's timezone, or utc if it isn't valid
+func timezone(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" {
+		return time.UTC
+	}
+	return loc
+}
+
+// limitedBuilder is a strings.Builder that errors instead of growing past maxTemplateOutput
+type limitedBuilder struct {
+	strings.Builder
+}
+
+func (b *limitedBuilder) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxTemplateOutput {
+		return 0, fmt.Errorf("output is longer than %d characters", maxTemplateOutput)
+	}
+	return b.Builder.Write(p)
+}
+
+// number formats n with commas between thousands, like 1,234
+func number(n int) string {
+	s := strconv.Itoa(n)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	var sb strings.Builder
+	if neg {
+		sb.WriteString("-")
+	}
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// ordinal formats n like 1,234th
+func ordinal(n int) string {
+	last := n % 100
+	if last < 0 {
+		last = -last
+	}
+	suffix := "th"
+	// 11th, 12th, and 13th are exceptions
+	if last/10 != 1 {
+		switch last % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return number(n) + suffix
+}
+
+// plural picks the singular or plural form of a word for n things
+func plural(n int, singular, plural string) string {
+	if n == 1 || n == -1 {
+		return singular
+	}
+	return plural
+}
+
+// age describes how long ago t was in its largest whole unit, like 3 years
+func age(t time.Time) string {
+	d := time.Since(t)
+	units := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"week", 7 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+	for _, u := range units {
+		if n := int(d / u.d); n > 0 {
+			return fmt.Sprintf("%d %s", n, plural(n, u.name, u.name+"s"))
+		}
+	}
+	return "less than a minute"
+}
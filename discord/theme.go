@@ -0,0 +1,83 @@
+package discord
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/ftqo/kirby/assets"
+	"github.com/ftqo/kirby/card"
+)
+
+const (
+	// theme colors are stored empty to use the layout's, or as auto to contrast with the background
+	themeDefault = ""
+	themeAuto    = "auto"
+)
+
+// parseThemeColor validates a theme color option, returning it as it gets stored
+func parseThemeColor(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "default":
+		return themeDefault, nil
+	case themeAuto:
+		return themeAuto, nil
+	}
+	c, err := assets.ParseColor(s)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A), nil
+}
+
+// usesAutoTheme reports whether any of a welcome's colors depend on its background
+func usesAutoTheme(w welcome) bool {
+	return w.OverlayColor == themeAuto || w.TextColor == themeAuto || w.RingColor == themeAuto
+}
+
+// theme builds the card theme of a welcome, bg is only used for auto colors
+func theme(w welcome, bg image.Image) card.Theme {
+	var auto card.Theme
+	if usesAutoTheme(w) && bg != nil {
+		auto = card.AutoTheme(bg)
+	}
+	t := card.Theme{
+		Overlay: themeColor(w.OverlayColor, auto.Overlay),
+		Text:    themeColor(w.TextColor, auto.Text),
+		Ring:    themeColor(w.RingColor, auto.Ring),
+	}
+	if w.OverlayOpacity >= 0 {
+		a := uint8(w.OverlayOpacity * 255 / 100)
+		t.OverlayAlpha = &a
+	}
+	if w.RingWidth >= 0 {
+		width := float64(w.RingWidth)
+		t.RingWidth = &width
+	}
+	t.Shape = w.AvatarShape
+	t.Frame = w.AvatarFrame
+	t.Shadow = w.AvatarShadow
+	return t
+}
+
+func themeColor(s string, auto *color.NRGBA) *color.NRGBA {
+	switch s {
+	case themeDefault:
+		return nil
+	case themeAuto:
+		return auto
+	}
+	c, err := assets.ParseColor(s)
+	if err != nil {
+		return nil
+	}
+	return &c
+}
+
+// themeKey identifies the theme settings of a welcome for caching
+func themeKey(w welcome) string {
+	return fmt.Sprintf("%s,%d,%s,%s,%d,%s,%t,%s", w.OverlayColor, w.OverlayOpacity, w.TextColor, w.RingColor, w.RingWidth,
+		w.AvatarShape, w.AvatarShadow, w.AvatarFrame)
+}
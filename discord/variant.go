@@ -0,0 +1,290 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/events"
+	"github.com/disgoorg/log"
+
+	"github.com/ftqo/kirby/database/queries"
+)
+
+const (
+	variantRandom     = "random"
+	variantRoundRobin = "round_robin"
+	maxVariants       = 20
+	// how much of each text /welcome variant list shows
+	variantPreviewLength = 50
+)
+
+var minVariantWeight, maxVariantWeight = 1, 100
+
+var variantModeChoices = []discord.ApplicationCommandOptionChoiceString{
+	{
+		Name:  "random",
+		Value: variantRandom,
+	}, {
+		Name:  "round robin",
+		Value: variantRoundRobin,
+	},
+}
+
+// variantPicker chooses which of a guild's variants welcomes the next member
+type variantPicker struct {
+	mu sync.Mutex
+	// the running weights of each guild's variants for round robin
+	current map[string]map[int32]int
+}
+
+func newVariantPicker() *variantPicker {
+	return &variantPicker{current: make(map[string]map[int32]int)}
+}
+
+// pick chooses a variant, randomly by weight or in a weighted round robin
+func (p *variantPicker) pick(guildID, mode string, variants []queries.WelcomeVariant) queries.WelcomeVariant {
+	total := 0
+	for _, v := range variants {
+		total += variantWeight(v)
+	}
+
+	if mode != variantRoundRobin {
+		r := rand.Intn(total)
+		for _, v := range variants {
+			r -= variantWeight(v)
+			if r < 0 {
+				return v
+			}
+		}
+	}
+
+	// smooth weighted round robin, which spreads out heavier variants instead of sending them in a row
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prev := p.current[guildID]
+	cur := make(map[int32]int, len(variants))
+	best := 0
+	for i, v := range variants {
+		cur[v.ID] = prev[v.ID] + variantWeight(v)
+		if cur[v.ID] > cur[variants[best].ID] {
+			best = i
+		}
+	}
+	cur[variants[best].ID] -= total
+	p.current[guildID] = cur
+	return variants[best]
+}
+
+func variantWeight(v queries.WelcomeVariant) int {
+	if v.Weight < 1 {
+		return 1
+	}
+	return int(v.Weight)
+}
+
+// withVariant swaps in the texts of one of the guild's variants, keeping the guild's own for any the variant leaves empty
+func (k *kirby) withVariant(log log.Logger, w welcome) welcome {
+	q := queries.New(k.db)
+	variants, err := q.GetWelcomeVariants(context.Background(), w.GuildID)
+	if err != nil {
+		log.Errorf("failed to get welcome variants from database: %v", err)
+		return w
+	}
+	if len(variants) == 0 {
+		return w
+	}
+	v := k.variants.pick(w.GuildID, w.VariantMode, variants)
+	if v.MessageText != "" {
+		w.MessageText = v.MessageText
+	}
+	if v.ImageTitle != "" {
+		w.ImageTitle = v.ImageTitle
+	}
+	if v.ImageSubtitle != "" {
+		w.ImageSubtitle = v.ImageSubtitle
+	}
+	return w
+}
+
+func (k *kirby) welcomeVariantGroup() discord.ApplicationCommandOptionSubCommandGroup {
+	return discord.ApplicationCommandOptionSubCommandGroup{
+		Name:        "variant",
+		Description: "commands for rotating between several welcome messages",
+		Options: []discord.ApplicationCommandOptionSubCommand{
+			{
+				Name:        "add",
+				Description: "add a variant, anything left out uses the welcome's own text",
+				Options: []discord.ApplicationCommandOption{
+					discord.ApplicationCommandOptionString{
+						Name:        "message",
+						Description: "the contents of the message",
+						Required:    false,
+					},
+					discord.ApplicationCommandOptionString{
+						Name:        "image_title",
+						Description: "the message in the top row of the image",
+						Required:    false,
+					},
+					discord.ApplicationCommandOptionString{
+						Name:        "image_subtitle",
+						Description: "the message in the bottom row of the image",
+						Required:    false,
+					},
+					discord.ApplicationCommandOptionInt{
+						Name:        "weight",
+						Description: "how often this variant is picked compared to the others, 1 by default",
+						Required:    false,
+						MinValue:    &minVariantWeight,
+						MaxValue:    &maxVariantWeight,
+					},
+				},
+			},
+			{
+				Name:        "remove",
+				Description: "remove a variant",
+				Options: []discord.ApplicationCommandOption{
+					discord.ApplicationCommandOptionInt{
+						Name:        "id",
+						Description: "the id of the variant, from /welcome variant list",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        "list",
+				Description: "list the welcome variants",
+			},
+			{
+				Name:        "mode",
+				Description: "set how the next variant is picked",
+				Options: []discord.ApplicationCommandOption{
+					discord.ApplicationCommandOptionString{
+						Name:        "mode",
+						Description: "random picks by weight, round robin takes turns by weight",
+						Required:    true,
+						Choices:     variantModeChoices,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (k *kirby) onWelcomeVariant(e *events.ApplicationCommandInteractionCreate) {
+	log := e.Client().Logger()
+	data := e.SlashCommandInteractionData()
+	q := queries.New(k.db)
+	guildID := e.GuildID().String()
+
+	reply := func(content string) {
+		err := e.CreateMessage(discord.NewMessageCreateBuilder().SetContent(content).SetEphemeral(true).Build())
+		if err != nil {
+			log.Errorf("failed to send message responding to welcome variant %s: %v", *data.SubCommandName, err)
+		}
+	}
+
+	switch *data.SubCommandName {
+	case "add":
+		message, _ := data.OptString("message")
+		title, _ := data.OptString("image_title")
+		subtitle, _ := data.OptString("image_subtitle")
+		if message == "" && title == "" && subtitle == "" {
+			reply("a variant needs a message, image title, or image subtitle!")
+			return
+		}
+		if err := validateTemplates(data.OptString, "message", "image_title", "image_subtitle"); err != nil {
+			reply(err.Error())
+			return
+		}
+		weight, ok := data.OptInt("weight")
+		if !ok {
+			weight = 1
+		}
+		variants, err := q.GetWelcomeVariants(context.Background(), guildID)
+		if err != nil {
+			log.Errorf("failed to get welcome variants for welcome variant add: %v", err)
+			reply("failed to add variant!")
+			return
+		}
+		if len(variants) >= maxVariants {
+			reply(fmt.Sprintf("a guild can have at most %d variants, remove one first!", maxVariants))
+			return
+		}
+		err = q.InsertWelcomeVariant(context.Background(), queries.InsertWelcomeVariantParams{
+			GuildID:       guildID,
+			MessageText:   message,
+			ImageTitle:    title,
+			ImageSubtitle: subtitle,
+			Weight:        int32(weight),
+		})
+		if err != nil {
+			log.Errorf("failed to insert welcome variant: %v", err)
+			reply("failed to add variant!")
+			return
+		}
+		reply("added welcome variant!")
+	case "remove":
+		n, err := q.DeleteWelcomeVariant(context.Background(), queries.DeleteWelcomeVariantParams{GuildID: guildID, ID: int32(data.Int("id"))})
+		if err != nil {
+			log.Errorf("failed to delete welcome variant: %v", err)
+			reply("failed to remove variant!")
+			return
+		}
+		if n == 0 {
+			reply(fmt.Sprintf("there is no variant %d, use `/welcome variant list` to see them!", data.Int("id")))
+			return
+		}
+		reply("removed welcome variant!")
+	case "list":
+		variants, err := q.GetWelcomeVariants(context.Background(), guildID)
+		if err != nil {
+			log.Errorf("failed to get welcome variants: %v", err)
+			reply("failed to get variants!")
+			return
+		}
+		if len(variants) == 0 {
+			reply("no variants, every welcome uses the text from `/welcome set`. use `/welcome variant add` to add one!")
+			return
+		}
+		mode := "random"
+		if w, err := q.GetWelcome(context.Background(), guildID); err == nil && w.VariantMode == variantRoundRobin {
+			mode = "round robin"
+		}
+		lines := []string{fmt.Sprintf("welcome variants, picked by %s:", mode)}
+		for _, v := range variants {
+			line := fmt.Sprintf("`%d` (weight %d)", v.ID, variantWeight(v))
+			for _, t := range []struct{ name, text string }{{"message", v.MessageText}, {"title", v.ImageTitle}, {"subtitle", v.ImageSubtitle}} {
+				if t.text != "" {
+					line += fmt.Sprintf(" %s: `%s`", t.name, preview(t.text, variantPreviewLength))
+				}
+			}
+			lines = append(lines, line)
+		}
+		reply(strings.Join(lines, "\n"))
+	case "mode":
+		err := q.InsertWelcome(context.Background(), defaultWelcome(guildID))
+		if err != nil {
+			log.Errorf("failed to insert default welcome for welcome variant mode: %v", err)
+		}
+		err = q.SetWelcomeVariantMode(context.Background(), queries.SetWelcomeVariantModeParams{GuildID: guildID, VariantMode: data.String("mode")})
+		if err != nil {
+			log.Errorf("failed to set variant mode for welcome variant mode: %v", err)
+			reply("failed to set variant mode!")
+			return
+		}
+		reply("set welcome variant mode!")
+	}
+}
+
+// preview shortens text to at most max characters, and keeps it from breaking out of inline code
+func preview(text string, max int) string {
+	text = strings.NewReplacer("`", "'", "\n", " ").Replace(text)
+	if r := []rune(text); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return text
+}
@@ -3,120 +3,159 @@ package discord
 import (
 	"bytes"
 	"context"
-	"image"
-	"image/color"
-	_ "image/gif"
+	"fmt"
 	_ "image/jpeg"
-	"image/png"
-	"net/http"
+	_ "image/png"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/disgoorg/disgo/discord"
 	"github.com/disgoorg/log"
+	"github.com/disgoorg/snowflake/v2"
 	"github.com/ftqo/kirby/assets"
+	"github.com/ftqo/kirby/card"
 	"github.com/ftqo/kirby/database/queries"
-	"github.com/golang/freetype/truetype"
-
-	"github.com/anthonynsimon/bild/transform"
-	"github.com/fogleman/gg"
 )
 
 const (
-	avatarSize = 256
-	width      = 848
-	height     = 477
-	margin     = 15
+	// discord's upload limit for guilds without boosts
+	defaultMaxUploadMiB = 8
+	maxAnimatedFrames   = 60
 )
 
 type welcome = queries.InsertWelcomeParams
 
 type welcomeReplace struct {
-	mention   string
-	nickname  string
-	username  string
-	guildName string
-	avatarURL string
-	members   int
+	userID      snowflake.ID
+	mention     string
+	nickname    string
+	username    string
+	displayName string
+	globalName  string
+	guildName   string
+	avatarURL   string
+	// used to cache the avatar, empty for default avatars
+	avatarHash string
+	// only set if the avatar is animated
+	animatedAvatarURL string
+	bot               bool
+	created           time.Time
+	joined            time.Time
+	members           int
+	boosts            int
+}
+
+// animatedAvatarURL returns the url of a user's avatar as a gif, or nothing if it isn't animated
+func animatedAvatarURL(u discord.User) string {
+	if u.Avatar == nil || !strings.HasPrefix(*u.Avatar, "a_") {
+		return ""
+	}
+	return u.EffectiveAvatarURL(discord.WithSize(256), discord.WithFormat(discord.ImageFormatGIF))
 }
 
-func generateWelcomeMessage(log log.Logger, w welcome, wr welcomeReplace, a *assets.Assets) discord.MessageCreate {
+func (k *kirby) renderAnimatedWelcome(l *assets.Layout, d card.Data, url string) ([]byte, error) {
+	g, err := k.avatars.animated(context.Background(), url)
+	if err != nil {
+		return nil, err
+	}
+	return card.RenderAnimated(k.assets, l, d, g, card.Budget{MaxFrames: maxAnimatedFrames, MaxBytes: k.render.MaxUploadMiB << 20})
+}
+
+func (k *kirby) generateWelcomeMessage(log log.Logger, w welcome, wr welcomeReplace) discord.MessageCreate {
 	log.Trace("generating welcome message")
 	var msg discord.MessageCreate
 
-	r := strings.NewReplacer("%mention%", wr.mention, "%nickname%", wr.nickname,
-		"%username%", wr.username, "%guild%", wr.guildName, "%members%", strconv.Itoa(wr.members))
-	w.MessageText = r.Replace(w.MessageText)
-	w.ImageTitle = r.Replace(w.ImageTitle)
-	w.ImageSubtitle = r.Replace(w.ImageSubtitle)
+	td := wr.templateData(timezone(w.Timezone))
+	fill := func(name string, text *string) {
+		out, err := executeTemplate(name, *text, td)
+		if err != nil {
+			// templates are checked when they are set, so this is rare enough to just send the text as is
+			log.Warnf("failed to execute %s template: %v", name, err)
+			return
+		}
+		*text = out
+	}
+	fill("message", &w.MessageText)
+	fill("image_title", &w.ImageTitle)
+	fill("image_subtitle", &w.ImageSubtitle)
+	fill("embed_title", &w.EmbedTitle)
+	fill("embed_description", &w.EmbedDescription)
+	fill("embed_footer", &w.EmbedFooter)
 
 	msg.Content = w.MessageText
 
 	switch w.MessageType {
 	case "embed":
-		log.Error("embedded welcome messages not implemented; sending plain")
+		eb := discord.NewEmbedBuilder().
+			SetTitle(w.EmbedTitle).
+			SetDescription(w.EmbedDescription).
+			SetColor(int(w.EmbedColor)).
+			SetThumbnail(wr.avatarURL).
+			SetFooterText(w.EmbedFooter)
+		if w.EmbedTimestamp {
+			eb.SetTimestamp(time.Now())
+		}
+		msg.Embeds = append(msg.Embeds, eb.Build())
 	case "image":
-		imageCtx := gg.NewContextForImage(a.Images[w.ImageName])
-		req, err := http.NewRequestWithContext(context.Background(), "GET", wr.avatarURL, nil)
+		pfp, err := k.avatars.imageOrDefault(context.Background(), wr.avatarHash, wr.avatarURL)
 		if err != nil {
-			log.Error("failed to generate request for user profile pic: ", err)
+			log.Warnf("failed to get avatar, using default: %v", err)
 		}
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			log.Error("failed to get profile picture response", err)
+
+		l, ok := k.assets.Layouts[w.LayoutName]
+		if !ok {
+			log.Warnf("unknown welcome layout %s, using %s", w.LayoutName, assets.DefaultLayout)
+			l = k.assets.Layouts[assets.DefaultLayout]
 		}
-		defer resp.Body.Close()
-		rawPfp, _, err := image.Decode(resp.Body)
+		base, err := k.cardBase(log, w, l)
 		if err != nil {
-			log.Error("failed to decode profile picture", err)
+			log.Errorf("failed to render welcome card background: %v", err)
+			return msg
+		}
+		wc := card.Welcome{
+			Layout:   l,
+			Base:     base.image,
+			Theme:    base.theme,
+			Font:     k.welcomeFont(log, w),
+			Faces:    k.faces,
+			Title:    w.ImageTitle,
+			Subtitle: w.ImageSubtitle,
+			Nickname: wr.nickname,
+			Username: wr.username,
+			Guild:    wr.guildName,
+			Members:  wr.members,
 		}
 
-		// resize if necessary
-		var pfp image.Image
-		if rawPfp.Bounds().Max.X != avatarSize {
-			pfp = image.Image(transform.Resize(rawPfp, avatarSize, avatarSize, transform.Linear))
-		} else {
-			pfp = rawPfp
+		// try an animated card first, falling back to a still one
+		if w.Animated && len(wr.animatedAvatarURL) != 0 {
+			anim, err := k.renderAnimatedWelcome(l, wc.Data(pfp), wr.animatedAvatarURL)
+			if err == nil {
+				msg.Files = append(msg.Files, &discord.File{
+					Name:   "welcome_" + wr.nickname + ".gif",
+					Reader: bytes.NewReader(anim),
+				})
+				return msg
+			}
+			log.Debugf("failed to render animated welcome card, sending still: %v", err)
 		}
 
-		// draw colored rectangle over image
-		imageCtx.SetColor(color.RGBA{50, 45, 50, 130})
-		imageCtx.DrawRectangle(margin, margin, width-(2*margin), height-(2*margin))
-		imageCtx.Fill()
-
-		// draw outline circle
-		imageCtx.SetColor(color.White)
-		imageCtx.DrawCircle(width/2, height*44/100, avatarSize/2+3)
-		imageCtx.SetLineWidth(5)
-		imageCtx.Stroke()
-
-		// draw pfp in a circle
-		imageCtx.DrawCircle(width/2, height*44/100, avatarSize/2)
-		imageCtx.Clip()
-		imageCtx.DrawImage(pfp, width/2-avatarSize/2, height*44/100-(avatarSize/2))
-		imageCtx.ResetClip()
-
-		// write title and subtitle
-		font := a.Fonts["coolvetica"]
-		face := truetype.NewFace(&font, &truetype.Options{Size: 40})
-		imageCtx.SetFontFace(face)
-		imageCtx.DrawStringAnchored(w.ImageTitle, width/2, height*78/100, 0.5, 0.5)
-		face = truetype.NewFace(&font, &truetype.Options{Size: 25})
-		imageCtx.SetFontFace(face)
-		imageCtx.DrawStringAnchored(w.ImageSubtitle, width/2, height*85/100, 0.5, 0.5)
+		img, err := wc.Render(k.assets, pfp)
+		if err != nil {
+			log.Errorf("failed to render welcome card: %v", err)
+			return msg
+		}
 
 		// encode and add file to message
-		buf := bytes.Buffer{}
-		enc := png.Encoder{
-			CompressionLevel: png.NoCompression,
-		}
-		err = enc.Encode(&buf, imageCtx.Image())
+		enc := k.encoding(w)
+		raw, err := card.Encode(img, enc)
 		if err != nil {
-			log.Error("failed to encode image into bytes buffer")
+			log.Errorf("failed to encode welcome card: %v", err)
+			return msg
 		}
 		f := &discord.File{
-			Name:   "welcome_" + wr.nickname + ".jpg",
-			Reader: &buf,
+			Name:   "welcome_" + wr.nickname + card.Extension(enc.Format),
+			Reader: bytes.NewReader(raw),
 		}
 		msg.Files = append(msg.Files, f)
 	}
@@ -124,6 +163,31 @@ func generateWelcomeMessage(log log.Logger, w welcome, wr welcomeReplace, a *ass
 	return msg
 }
 
+// encoding returns how a guild's cards are encoded, using the global settings for anything it hasn't set
+func (k *kirby) encoding(w welcome) card.Encoding {
+	e := card.Encoding{
+		Format:   k.render.Format,
+		Quality:  k.render.Quality,
+		MaxBytes: k.render.MaxUploadMiB << 20,
+	}
+	if validFormat(w.ImageFormat) {
+		e.Format = w.ImageFormat
+	}
+	if w.ImageQuality > 0 {
+		e.Quality = int(w.ImageQuality)
+	}
+	return e
+}
+
+func validFormat(format string) bool {
+	for _, f := range card.Formats {
+		if format == f {
+			return true
+		}
+	}
+	return false
+}
+
 func defaultWelcome(gid string) welcome {
 	return welcome{
 		GuildID:       gid,
@@ -133,5 +197,47 @@ func defaultWelcome(gid string) welcome {
 		ImageName:     "original",
 		ImageTitle:    "%username% joined the server",
 		ImageSubtitle: "member #%members%",
+		LayoutName:    assets.DefaultLayout,
+		Animated:      false,
+		ImageFormat:   "",
+		ImageQuality:  0,
+
+		OverlayColor:   themeDefault,
+		OverlayOpacity: -1,
+		TextColor:      themeDefault,
+		RingColor:      themeDefault,
+		RingWidth:      -1,
+		FontName:       defaultFontName,
+		BatchThreshold: 0,
+		BatchWindow:    10,
+		DmEnabled:      false,
+		DmText:         defaultDMText,
+		DmCard:         false,
+		DmFallback:     false,
+		Timezone:       defaultTimezone,
+		VariantMode:    variantRandom,
+		WaveButton:     false,
+		AvatarShape:    "",
+		AvatarShadow:   false,
+		AvatarFrame:    "",
+
+		EmbedTitle:       "%username% joined the server",
+		EmbedDescription: "welcome to %guild%! you are member #%members%",
+		EmbedColor:       0xf7a8c4,
+		EmbedFooter:      "",
+		EmbedTimestamp:   true,
+	}
+}
+
+// parseHexColor parses colors in the form of #rrggbb or rrggbb
+func parseHexColor(s string) (int32, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 {
+		return 0, fmt.Errorf("%q is not a 6 digit hex color", s)
+	}
+	c, err := strconv.ParseInt(s, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid hex color: %v", s, err)
 	}
+	return int32(c), nil
 }
@@ -1,17 +1,21 @@
 module github.com/ftqo/kirby
 
-go 1.18
+// nativewebp, the only pure go webp encoder, needs go 1.22.2
+go 1.22.2
 
 require (
+	github.com/HugoSmits86/nativewebp v0.9.3
 	github.com/anthonynsimon/bild v0.13.0
-	github.com/disgoorg/disgo v0.13.8
+	github.com/disgoorg/disgo v0.16.6
+	github.com/disgoorg/json v1.1.0
 	github.com/disgoorg/log v1.2.0
-	github.com/disgoorg/snowflake/v2 v2.0.0
+	github.com/disgoorg/snowflake/v2 v2.0.1
 	github.com/fogleman/gg v1.3.0
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/gorilla/websocket v1.5.0
 	github.com/jackc/pgx/v4 v4.16.1
 	github.com/sirupsen/logrus v1.9.0
+	golang.org/x/image v0.5.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
@@ -27,8 +31,7 @@ require (
 	github.com/sasha-s/go-csync v0.0.0-20210812194225-61421b77c44b // indirect
 	golang.org/x/crypto v0.0.0-20220722155217-630584e8d5aa // indirect
 	golang.org/x/exp v0.0.0-20220722155223-a9213eeb770e // indirect
-	golang.org/x/image v0.5.0 // indirect
-	golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f // indirect
+	golang.org/x/sys v0.1.0 // indirect
 	golang.org/x/text v0.7.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
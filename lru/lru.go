@@ -0,0 +1,115 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a least recently used cache limited by the total cost of its entries, safe for concurrent use
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	maxCost int64
+	cost    int64
+	items   map[K]*list.Element
+	order   *list.List
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+	cost  int64
+}
+
+// Stats are counters since the last time stats were reset, along with the current size of the cache
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Len       int
+	Cost      int64
+}
+
+// New creates a cache that evicts entries once their costs add up to more than maxCost
+func New[K comparable, V any](maxCost int64) *Cache[K, V] {
+	return &Cache[K, V]{
+		maxCost: maxCost,
+		items:   make(map[K]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		var zero V
+		return zero, false
+	}
+	c.hits++
+	c.order.MoveToFront(el)
+	return el.Value.(*entry[K, V]).value, true
+}
+
+// Add inserts or replaces an entry, doing nothing if it costs more than the whole cache can hold
+func (c *Cache[K, V]) Add(key K, value V, cost int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cost > c.maxCost {
+		return
+	}
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, cost: cost})
+	c.cost += cost
+	for c.cost > c.maxCost {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+func (c *Cache[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// RemoveFunc removes every entry whose key matches
+func (c *Cache[K, V]) RemoveFunc(match func(key K) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, el := range c.items {
+		if match(k) {
+			c.remove(el)
+		}
+	}
+}
+
+func (c *Cache[K, V]) remove(el *list.Element) {
+	e := c.order.Remove(el).(*entry[K, V])
+	delete(c.items, e.key)
+	c.cost -= e.cost
+}
+
+// Stats returns and resets the cache's counters
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := Stats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Len:       len(c.items),
+		Cost:      c.cost,
+	}
+	c.hits, c.misses, c.evictions = 0, 0, 0
+	return s
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 
+	"github.com/ftqo/kirby/api"
 	"github.com/ftqo/kirby/assets"
 	"github.com/ftqo/kirby/config"
 	"github.com/ftqo/kirby/database"
@@ -14,6 +16,10 @@ import (
 )
 
 func main() {
+	if runSubcommand(os.Args[1:]) {
+		return
+	}
+
 	wg := &sync.WaitGroup{}
 	ctx, _ := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 
@@ -35,7 +41,9 @@ func main() {
 	}
 
 	wg.Add(1)
-	go discord.Run(ctx, wg, log, c.DiscordConfig, db, a)
+	go discord.Run(ctx, wg, log, c.DiscordConfig, c.RenderConfig, db, a)
+	wg.Add(1)
+	go api.Run(ctx, wg, log, c.APIConfig, c.RenderConfig, a)
 
 	wg.Wait()
 }
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ftqo/kirby/assets"
+	"github.com/ftqo/kirby/card"
+	"github.com/ftqo/kirby/config"
+	"github.com/ftqo/kirby/logger"
+)
+
+// renderInput is what kirby render draws a card from, read from a json file and flags
+type renderInput struct {
+	Layout     string `json:"layout"`
+	Background string `json:"background"`
+	Title      string `json:"title"`
+	Subtitle   string `json:"subtitle"`
+	Avatar     string `json:"avatar"`
+	Nickname   string `json:"nickname"`
+	Username   string `json:"username"`
+	Members    int    `json:"members"`
+	Guild      string `json:"guild"`
+	Shape      string `json:"shape"`
+	Shadow     bool   `json:"shadow"`
+	Frame      string `json:"frame"`
+	Format     string `json:"format"`
+	Quality    int    `json:"quality"`
+	Output     string `json:"output"`
+}
+
+// renderCard renders a welcome card to a file without connecting to discord or the database, for designing layouts
+func renderCard(args []string) error {
+	in := renderInput{
+		Layout:     assets.DefaultLayout,
+		Background: "original",
+		Title:      "kirby joined the server",
+		Nickname:   "kirby",
+		Username:   "kirby#0000",
+		Members:    1234,
+		Guild:      "dream land",
+		Format:     card.FormatPNG,
+		Quality:    card.DefaultQuality,
+	}
+
+	fs := flag.NewFlagSet("kirby render", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: kirby render [flags]\n\nrenders a welcome card to a file, flags override the json input")
+		fs.PrintDefaults()
+	}
+	input := fs.String("input", "", "a json `file` with any of the fields below, named like the flags")
+	fs.StringVar(&in.Layout, "layout", in.Layout, "the card layout")
+	fs.StringVar(&in.Background, "background", in.Background, "an embedded background name, or the path of an image")
+	fs.StringVar(&in.Title, "title", in.Title, "the text in the top row")
+	fs.StringVar(&in.Subtitle, "subtitle", in.Subtitle, "the text in the bottom row, member #<members> if empty")
+	fs.StringVar(&in.Avatar, "avatar", in.Avatar, "the path of an avatar image, the default avatar if empty")
+	fs.StringVar(&in.Nickname, "nickname", in.Nickname, "the member's name, for layouts that show it")
+	fs.StringVar(&in.Username, "username", in.Username, "the member's name with discriminator, for layouts that show it")
+	fs.IntVar(&in.Members, "members", in.Members, "the guild's member count")
+	fs.StringVar(&in.Guild, "guild", in.Guild, "the guild's name")
+	fs.StringVar(&in.Shape, "shape", in.Shape, "the avatar shape: "+strings.Join(assets.AvatarShapes, ", ")+", the layout's if empty")
+	fs.BoolVar(&in.Shadow, "shadow", in.Shadow, "draw a shadow under the avatar")
+	fs.StringVar(&in.Frame, "frame", in.Frame, "the name of an embedded frame to draw around the avatar")
+	fs.StringVar(&in.Format, "format", in.Format, "the image format: png, jpeg, or webp")
+	fs.IntVar(&in.Quality, "quality", in.Quality, "the jpeg quality, from 1 to 100")
+	fs.StringVar(&in.Output, "o", in.Output, "the `file` to write, welcome.<format> by default")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *input != "" {
+		// flags win over the file, so remember them before the file overwrites them
+		set := map[string]string{}
+		fs.Visit(func(f *flag.Flag) {
+			set[f.Name] = f.Value.String()
+		})
+		raw, err := os.ReadFile(*input)
+		if err != nil {
+			return fmt.Errorf("failed to read input: %v", err)
+		}
+		err = json.Unmarshal(raw, &in)
+		if err != nil {
+			return fmt.Errorf("failed to parse input: %v", err)
+		}
+		for name, value := range set {
+			fs.Set(name, value)
+		}
+	}
+
+	if in.Subtitle == "" {
+		in.Subtitle = "member #" + strconv.Itoa(in.Members)
+	}
+
+	a, err := assets.GetAssets(logger.GetLogger(config.LogConfig{Level: "warn"}))
+	if err != nil {
+		return fmt.Errorf("failed to get assets: %v", err)
+	}
+	l, ok := a.Layouts[in.Layout]
+	if !ok {
+		return fmt.Errorf("unknown layout %q", in.Layout)
+	}
+	bg, ok := a.Images[in.Background]
+	if !ok {
+		bg, err = decodeFile(in.Background)
+		if err != nil {
+			return fmt.Errorf("background is neither an embedded image nor a readable image file: %v", err)
+		}
+	}
+	if in.Shape != "" && !slices.Contains(assets.AvatarShapes, in.Shape) {
+		return fmt.Errorf("unknown avatar shape %q", in.Shape)
+	}
+	if _, ok := a.Frames[in.Frame]; in.Frame != "" && !ok {
+		return fmt.Errorf("unknown avatar frame %q", in.Frame)
+	}
+	var pfp image.Image = card.DefaultAvatar(512)
+	if in.Avatar != "" {
+		pfp, err = decodeFile(in.Avatar)
+		if err != nil {
+			return fmt.Errorf("failed to read avatar: %v", err)
+		}
+	}
+
+	wc := card.Welcome{
+		Layout:     l,
+		Background: bg,
+		Theme:      card.Theme{Shape: in.Shape, Shadow: in.Shadow, Frame: in.Frame},
+		Title:      in.Title,
+		Subtitle:   in.Subtitle,
+		Nickname:   in.Nickname,
+		Username:   in.Username,
+		Guild:      in.Guild,
+		Members:    in.Members,
+	}
+	img, err := wc.Render(a, pfp)
+	if err != nil {
+		return fmt.Errorf("failed to render card: %v", err)
+	}
+	raw, err := card.Encode(img, card.Encoding{Format: in.Format, Quality: in.Quality})
+	if err != nil {
+		return fmt.Errorf("failed to encode card: %v", err)
+	}
+	if in.Output == "" {
+		in.Output = "welcome" + card.Extension(in.Format)
+	}
+	err = os.WriteFile(in.Output, raw, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write card: %v", err)
+	}
+	fmt.Println("wrote " + in.Output)
+	return nil
+}
+
+func decodeFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// runSubcommand runs a subcommand like kirby render, reporting whether there was one
+func runSubcommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	var err error
+	switch args[0] {
+	case "render":
+		err = renderCard(args[1:])
+	default:
+		return false
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "kirby "+args[0]+": "+err.Error())
+		os.Exit(1)
+	}
+	return true
+}
@@ -11,6 +11,10 @@ setup() {
     PGPASSWORD=$DEV_PASSWORD psql --host=$PGHOST --port=$PGPORT --username=$DEV_USERNAME $DEV_DATABASE -f "sqlc/schema.sql"
 }
 
+upgrade() {
+    PGPASSWORD=$DEV_PASSWORD psql --host=$PGHOST --port=$PGPORT --username=$DEV_USERNAME $DEV_DATABASE -f "sqlc/upgrade.sql"
+}
+
 teardown() {
     PGPASSWORD=$PGPASSWORD psql --host=$PGHOST --username=$PGUSER $PGDATABASE -c "SELECT pg_terminate_backend(pg_stat_activity.pid) FROM pg_stat_activity WHERE pg_stat_activity.datname = '$DEV_DATABASE' AND pid <> pg_backend_pid()"
     PGPASSWORD=$PGPASSWORD psql --host=$PGHOST --username=$PGUSER $PGDATABASE -c "DROP DATABASE $DEV_DATABASE"
@@ -21,11 +25,14 @@ case "$1" in
 setup)
     setup
 ;;
+upgrade)
+    upgrade
+;;
 teardown)
     teardown
 ;;
 *)
-echo "Usage: $0 (setup|teardown)"
+echo "Usage: $0 (setup|upgrade|teardown)"
 ;;
 esac
 
@@ -8,8 +8,13 @@ INSERT INTO kv_pairs (k, v)
 SELECT v FROM kv_pairs WHERE k = $1;
 
 -- name: InsertWelcome :exec
-INSERT INTO welcomes (guild_id, channel_id, message_type, message_text, image_name, image_title, image_subtitle)
-	VALUES ($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO welcomes (guild_id, channel_id, message_type, message_text, image_name, image_title, image_subtitle,
+		embed_title, embed_description, embed_color, embed_footer, embed_timestamp, layout_name, animated,
+		image_format, image_quality, overlay_color, overlay_opacity, text_color, ring_color, ring_width, font_name,
+		batch_threshold, batch_window, dm_enabled, dm_text, dm_card, dm_fallback, timezone,
+		variant_mode, wave_button, avatar_shape, avatar_shadow, avatar_frame)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24,
+		$25, $26, $27, $28, $29, $30, $31, $32, $33, $34)
 	ON CONFLICT (guild_id) DO NOTHING;
 
 -- name: GetWelcome :one
@@ -33,5 +38,225 @@ UPDATE welcomes SET image_title = $1 WHERE guild_id = $2;
 -- name: SetWelcomeImageSubtitle :exec
 UPDATE welcomes SET image_subtitle = $1 WHERE guild_id = $2;
 
+-- name: SetWelcomeEmbedTitle :exec
+UPDATE welcomes SET embed_title = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeEmbedDescription :exec
+UPDATE welcomes SET embed_description = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeEmbedColor :exec
+UPDATE welcomes SET embed_color = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeEmbedFooter :exec
+UPDATE welcomes SET embed_footer = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeEmbedTimestamp :exec
+UPDATE welcomes SET embed_timestamp = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeLayoutName :exec
+UPDATE welcomes SET layout_name = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeAnimated :exec
+UPDATE welcomes SET animated = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeImageFormat :exec
+UPDATE welcomes SET image_format = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeImageQuality :exec
+UPDATE welcomes SET image_quality = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeOverlayColor :exec
+UPDATE welcomes SET overlay_color = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeOverlayOpacity :exec
+UPDATE welcomes SET overlay_opacity = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeTextColor :exec
+UPDATE welcomes SET text_color = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeRingColor :exec
+UPDATE welcomes SET ring_color = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeRingWidth :exec
+UPDATE welcomes SET ring_width = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeFontName :exec
+UPDATE welcomes SET font_name = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeTimezone :exec
+UPDATE welcomes SET timezone = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeVariantMode :exec
+UPDATE welcomes SET variant_mode = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeWaveButton :exec
+UPDATE welcomes SET wave_button = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeAvatarShape :exec
+UPDATE welcomes SET avatar_shape = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeAvatarShadow :exec
+UPDATE welcomes SET avatar_shadow = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeAvatarFrame :exec
+UPDATE welcomes SET avatar_frame = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeBatch :exec
+UPDATE welcomes SET batch_threshold = $1, batch_window = $2 WHERE guild_id = $3;
+
+-- name: SetWelcomeDMEnabled :exec
+UPDATE welcomes SET dm_enabled = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeDMText :exec
+UPDATE welcomes SET dm_text = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeDMCard :exec
+UPDATE welcomes SET dm_card = $1 WHERE guild_id = $2;
+
+-- name: SetWelcomeDMFallback :exec
+UPDATE welcomes SET dm_fallback = $1 WHERE guild_id = $2;
+
 -- name: DeleteWelcome :exec
 DELETE FROM welcomes WHERE guild_id = $1;
+
+-- name: UpsertWelcomeImage :exec
+INSERT INTO welcome_images (guild_id, image)
+	VALUES ($1, $2)
+	ON CONFLICT (guild_id) DO UPDATE
+	SET image = $2;
+
+-- name: GetWelcomeImage :one
+SELECT image FROM welcome_images WHERE guild_id = $1;
+
+-- name: DeleteWelcomeImage :exec
+DELETE FROM welcome_images WHERE guild_id = $1;
+
+-- name: UpsertWelcomeFont :exec
+INSERT INTO welcome_fonts (guild_id, font)
+	VALUES ($1, $2)
+	ON CONFLICT (guild_id) DO UPDATE
+	SET font = $2;
+
+-- name: GetWelcomeFont :one
+SELECT font FROM welcome_fonts WHERE guild_id = $1;
+
+-- name: DeleteWelcomeFont :exec
+DELETE FROM welcome_fonts WHERE guild_id = $1;
+
+-- name: InsertGoodbye :exec
+INSERT INTO goodbyes (guild_id, channel_id, message_type, message_text, image_name, image_title, image_subtitle,
+		embed_title, embed_description, embed_color, embed_footer, embed_timestamp)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	ON CONFLICT (guild_id) DO NOTHING;
+
+-- name: GetGoodbye :one
+SELECT * FROM goodbyes WHERE guild_id = $1;
+
+-- name: SetGoodbyeChannel :exec
+UPDATE goodbyes SET channel_id = $1 WHERE guild_id = $2;
+
+-- name: SetGoodbyeMessageType :exec
+UPDATE goodbyes SET message_type = $1 WHERE guild_id = $2;
+
+-- name: SetGoodbyeMessageText :exec
+UPDATE goodbyes SET message_text = $1 WHERE guild_id = $2;
+
+-- name: SetGoodbyeImageName :exec
+UPDATE goodbyes SET image_name = $1 WHERE guild_id = $2;
+
+-- name: SetGoodbyeImageTitle :exec
+UPDATE goodbyes SET image_title = $1 WHERE guild_id = $2;
+
+-- name: SetGoodbyeImageSubtitle :exec
+UPDATE goodbyes SET image_subtitle = $1 WHERE guild_id = $2;
+
+-- name: SetGoodbyeEmbedTitle :exec
+UPDATE goodbyes SET embed_title = $1 WHERE guild_id = $2;
+
+-- name: SetGoodbyeEmbedDescription :exec
+UPDATE goodbyes SET embed_description = $1 WHERE guild_id = $2;
+
+-- name: SetGoodbyeEmbedColor :exec
+UPDATE goodbyes SET embed_color = $1 WHERE guild_id = $2;
+
+-- name: SetGoodbyeEmbedFooter :exec
+UPDATE goodbyes SET embed_footer = $1 WHERE guild_id = $2;
+
+-- name: SetGoodbyeEmbedTimestamp :exec
+UPDATE goodbyes SET embed_timestamp = $1 WHERE guild_id = $2;
+
+-- name: DeleteGoodbye :exec
+DELETE FROM goodbyes WHERE guild_id = $1;
+
+-- name: UpsertJoinRole :exec
+INSERT INTO join_roles (guild_id, role_id, delay_seconds, skip_bots)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (guild_id, role_id) DO UPDATE
+	SET delay_seconds = $3, skip_bots = $4;
+
+-- name: GetJoinRoles :many
+SELECT * FROM join_roles WHERE guild_id = $1 ORDER BY role_id;
+
+-- name: DeleteJoinRole :execrows
+DELETE FROM join_roles WHERE guild_id = $1 AND role_id = $2;
+
+-- name: InsertWelcomeVariant :exec
+INSERT INTO welcome_variants (guild_id, message_text, image_title, image_subtitle, weight)
+	VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetWelcomeVariants :many
+SELECT * FROM welcome_variants WHERE guild_id = $1 ORDER BY id;
+
+-- name: DeleteWelcomeVariant :execrows
+DELETE FROM welcome_variants WHERE guild_id = $1 AND id = $2;
+
+-- name: UpsertWelcomeButton :exec
+INSERT INTO welcome_buttons (guild_id, label, url)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (guild_id, label) DO UPDATE
+	SET url = $3;
+
+-- name: GetWelcomeButtons :many
+SELECT * FROM welcome_buttons WHERE guild_id = $1 ORDER BY label;
+
+-- name: DeleteWelcomeButton :execrows
+DELETE FROM welcome_buttons WHERE guild_id = $1 AND label = $2;
+
+-- name: InsertWelcomeWave :execrows
+INSERT INTO welcome_waves (message_id, user_id)
+	VALUES ($1, $2)
+	ON CONFLICT (message_id, user_id) DO NOTHING;
+
+-- name: CountWelcomeWaves :one
+SELECT COUNT(*) FROM welcome_waves WHERE message_id = $1;
+
+-- name: DeleteWelcomeWavesBefore :execrows
+DELETE FROM welcome_waves WHERE waved_at < $1;
+
+-- name: InsertMilestone :exec
+INSERT INTO milestones (guild_id, channel_id, every, numbers, message_text)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (guild_id) DO NOTHING;
+
+-- name: GetMilestone :one
+SELECT * FROM milestones WHERE guild_id = $1;
+
+-- name: SetMilestoneChannel :exec
+UPDATE milestones SET channel_id = $1 WHERE guild_id = $2;
+
+-- name: SetMilestoneEvery :exec
+UPDATE milestones SET every = $1 WHERE guild_id = $2;
+
+-- name: SetMilestoneNumbers :exec
+UPDATE milestones SET numbers = $1 WHERE guild_id = $2;
+
+-- name: SetMilestoneMessageText :exec
+UPDATE milestones SET message_text = $1 WHERE guild_id = $2;
+
+-- name: InsertReachedMilestone :execrows
+INSERT INTO reached_milestones (guild_id, members)
+	VALUES ($1, $2)
+	ON CONFLICT (guild_id, members) DO NOTHING;
+
+-- name: GetReachedMilestones :many
+SELECT members FROM reached_milestones WHERE guild_id = $1 ORDER BY members DESC;
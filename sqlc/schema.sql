@@ -6,11 +6,117 @@ CREATE TABLE kv_pairs
 
 CREATE TABLE welcomes
   (
-     guild_id       VARCHAR PRIMARY KEY,
-     channel_id     VARCHAR NOT NULL,
-     message_type   VARCHAR NOT NULL,
+     guild_id          VARCHAR PRIMARY KEY,
+     channel_id        VARCHAR NOT NULL,
+     message_type      VARCHAR NOT NULL,
+     message_text      VARCHAR NOT NULL,
+     image_name        VARCHAR NOT NULL,
+     image_title       VARCHAR NOT NULL,
+     image_subtitle    VARCHAR NOT NULL,
+     embed_title       VARCHAR NOT NULL DEFAULT '%username% joined the server',
+     embed_description VARCHAR NOT NULL DEFAULT 'welcome to %guild%! you are member #%members%',
+     embed_color       INTEGER NOT NULL DEFAULT 16230596,
+     embed_footer      VARCHAR NOT NULL DEFAULT '',
+     embed_timestamp   BOOLEAN NOT NULL DEFAULT TRUE,
+     layout_name       VARCHAR NOT NULL DEFAULT 'classic',
+     animated          BOOLEAN NOT NULL DEFAULT FALSE,
+     image_format      VARCHAR NOT NULL DEFAULT '',
+     image_quality     INTEGER NOT NULL DEFAULT 0,
+     overlay_color     VARCHAR NOT NULL DEFAULT '',
+     overlay_opacity   INTEGER NOT NULL DEFAULT -1,
+     text_color        VARCHAR NOT NULL DEFAULT '',
+     ring_color        VARCHAR NOT NULL DEFAULT '',
+     ring_width        INTEGER NOT NULL DEFAULT -1,
+     font_name         VARCHAR NOT NULL DEFAULT '',
+     batch_threshold   INTEGER NOT NULL DEFAULT 0,
+     batch_window      INTEGER NOT NULL DEFAULT 10,
+     dm_enabled        BOOLEAN NOT NULL DEFAULT FALSE,
+     dm_text           VARCHAR NOT NULL DEFAULT 'hi %mention%, welcome to %guild%! make sure to read the rules :)',
+     dm_card           BOOLEAN NOT NULL DEFAULT FALSE,
+     dm_fallback       BOOLEAN NOT NULL DEFAULT FALSE,
+     timezone          VARCHAR NOT NULL DEFAULT 'UTC',
+     variant_mode      VARCHAR NOT NULL DEFAULT 'random',
+     wave_button       BOOLEAN NOT NULL DEFAULT FALSE,
+     avatar_shape      VARCHAR NOT NULL DEFAULT '',
+     avatar_shadow     BOOLEAN NOT NULL DEFAULT FALSE,
+     avatar_frame      VARCHAR NOT NULL DEFAULT ''
+  );
+
+CREATE TABLE welcome_images
+  (
+     guild_id VARCHAR PRIMARY KEY,
+     image    BYTEA NOT NULL
+  );
+
+CREATE TABLE welcome_fonts
+  (
+     guild_id VARCHAR PRIMARY KEY,
+     font     BYTEA NOT NULL
+  );
+
+CREATE TABLE goodbyes
+  (
+     guild_id          VARCHAR PRIMARY KEY,
+     channel_id        VARCHAR NOT NULL,
+     message_type      VARCHAR NOT NULL,
+     message_text      VARCHAR NOT NULL,
+     image_name        VARCHAR NOT NULL,
+     image_title       VARCHAR NOT NULL,
+     image_subtitle    VARCHAR NOT NULL,
+     embed_title       VARCHAR NOT NULL,
+     embed_description VARCHAR NOT NULL,
+     embed_color       INTEGER NOT NULL,
+     embed_footer      VARCHAR NOT NULL,
+     embed_timestamp   BOOLEAN NOT NULL
+  );
+
+CREATE TABLE join_roles
+  (
+     guild_id      VARCHAR NOT NULL,
+     role_id       VARCHAR NOT NULL,
+     delay_seconds INTEGER NOT NULL,
+     skip_bots     BOOLEAN NOT NULL,
+     PRIMARY KEY (guild_id, role_id)
+  );
+
+CREATE TABLE welcome_variants
+  (
+     id             SERIAL PRIMARY KEY,
+     guild_id       VARCHAR NOT NULL,
      message_text   VARCHAR NOT NULL,
-     image_name     VARCHAR NOT NULL,
      image_title    VARCHAR NOT NULL,
-     image_subtitle VARCHAR NOT NULL
-  ); 
\ No newline at end of file
+     image_subtitle VARCHAR NOT NULL,
+     weight         INTEGER NOT NULL
+  );
+
+CREATE TABLE welcome_buttons
+  (
+     guild_id VARCHAR NOT NULL,
+     label    VARCHAR NOT NULL,
+     url      VARCHAR NOT NULL,
+     PRIMARY KEY (guild_id, label)
+  );
+
+CREATE TABLE welcome_waves
+  (
+     message_id VARCHAR NOT NULL,
+     user_id    VARCHAR NOT NULL,
+     waved_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+     PRIMARY KEY (message_id, user_id)
+  );
+
+CREATE TABLE milestones
+  (
+     guild_id     VARCHAR PRIMARY KEY,
+     channel_id   VARCHAR NOT NULL,
+     every        INTEGER NOT NULL,
+     numbers      VARCHAR NOT NULL,
+     message_text VARCHAR NOT NULL
+  );
+
+CREATE TABLE reached_milestones
+  (
+     guild_id VARCHAR NOT NULL,
+     members  INTEGER NOT NULL,
+     PRIMARY KEY (guild_id, members)
+  );
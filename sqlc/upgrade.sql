@@ -0,0 +1,111 @@
+-- brings a database made from an older schema.sql up to date, it can be run any number of times
+-- new welcomes columns get the same defaults as defaultWelcome, so existing guilds keep working
+
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS embed_title       VARCHAR NOT NULL DEFAULT '%username% joined the server';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS embed_description VARCHAR NOT NULL DEFAULT 'welcome to %guild%! you are member #%members%';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS embed_color       INTEGER NOT NULL DEFAULT 16230596;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS embed_footer      VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS embed_timestamp   BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS layout_name       VARCHAR NOT NULL DEFAULT 'classic';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS animated          BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS image_format      VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS image_quality     INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS overlay_color     VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS overlay_opacity   INTEGER NOT NULL DEFAULT -1;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS text_color        VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS ring_color        VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS ring_width        INTEGER NOT NULL DEFAULT -1;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS font_name         VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS batch_threshold   INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS batch_window      INTEGER NOT NULL DEFAULT 10;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS dm_enabled        BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS dm_text           VARCHAR NOT NULL DEFAULT 'hi %mention%, welcome to %guild%! make sure to read the rules :)';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS dm_card           BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS dm_fallback       BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS timezone          VARCHAR NOT NULL DEFAULT 'UTC';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS variant_mode      VARCHAR NOT NULL DEFAULT 'random';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS wave_button       BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS avatar_shape      VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS avatar_shadow     BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE welcomes ADD COLUMN IF NOT EXISTS avatar_frame      VARCHAR NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS welcome_images
+  (
+     guild_id VARCHAR PRIMARY KEY,
+     image    BYTEA NOT NULL
+  );
+
+CREATE TABLE IF NOT EXISTS welcome_fonts
+  (
+     guild_id VARCHAR PRIMARY KEY,
+     font     BYTEA NOT NULL
+  );
+
+CREATE TABLE IF NOT EXISTS goodbyes
+  (
+     guild_id          VARCHAR PRIMARY KEY,
+     channel_id        VARCHAR NOT NULL,
+     message_type      VARCHAR NOT NULL,
+     message_text      VARCHAR NOT NULL,
+     image_name        VARCHAR NOT NULL,
+     image_title       VARCHAR NOT NULL,
+     image_subtitle    VARCHAR NOT NULL,
+     embed_title       VARCHAR NOT NULL,
+     embed_description VARCHAR NOT NULL,
+     embed_color       INTEGER NOT NULL,
+     embed_footer      VARCHAR NOT NULL,
+     embed_timestamp   BOOLEAN NOT NULL
+  );
+
+CREATE TABLE IF NOT EXISTS join_roles
+  (
+     guild_id      VARCHAR NOT NULL,
+     role_id       VARCHAR NOT NULL,
+     delay_seconds INTEGER NOT NULL,
+     skip_bots     BOOLEAN NOT NULL,
+     PRIMARY KEY (guild_id, role_id)
+  );
+
+CREATE TABLE IF NOT EXISTS welcome_variants
+  (
+     id             SERIAL PRIMARY KEY,
+     guild_id       VARCHAR NOT NULL,
+     message_text   VARCHAR NOT NULL,
+     image_title    VARCHAR NOT NULL,
+     image_subtitle VARCHAR NOT NULL,
+     weight         INTEGER NOT NULL
+  );
+
+CREATE TABLE IF NOT EXISTS welcome_buttons
+  (
+     guild_id VARCHAR NOT NULL,
+     label    VARCHAR NOT NULL,
+     url      VARCHAR NOT NULL,
+     PRIMARY KEY (guild_id, label)
+  );
+
+CREATE TABLE IF NOT EXISTS welcome_waves
+  (
+     message_id VARCHAR NOT NULL,
+     user_id    VARCHAR NOT NULL,
+     waved_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+     PRIMARY KEY (message_id, user_id)
+  );
+
+ALTER TABLE welcome_waves ADD COLUMN IF NOT EXISTS waved_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE TABLE IF NOT EXISTS milestones
+  (
+     guild_id     VARCHAR PRIMARY KEY,
+     channel_id   VARCHAR NOT NULL,
+     every        INTEGER NOT NULL,
+     numbers      VARCHAR NOT NULL,
+     message_text VARCHAR NOT NULL
+  );
+
+CREATE TABLE IF NOT EXISTS reached_milestones
+  (
+     guild_id VARCHAR NOT NULL,
+     members  INTEGER NOT NULL,
+     PRIMARY KEY (guild_id, members)
+  );